@@ -0,0 +1,80 @@
+package twitchchannelpointsminer
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+var balanceCSVHeader = []string{"timestamp", "streamer", "channel_points", "online"}
+
+// ? balanceSampler appends every streamer's balance to BalanceCSVPath once per BalanceSampleInterval.
+// ? The file is opened per sample so it can be rotated or read by other tools while the miner runs.
+func (m *Miner) balanceSampler(streamers []*entities.Streamer, stop <-chan struct{}) {
+	if m.BalanceCSVPath == "" {
+		return
+	}
+	interval := m.BalanceSampleInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	m.logger.Printf("Sampling balances every %s to %s", interval, m.BalanceCSVPath)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := m.appendBalanceSample(streamers, now); err != nil {
+				m.logger.Errorf("balance sample: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *Miner) appendBalanceSample(streamers []*entities.Streamer, now time.Time) error {
+	if dir := filepath.Dir(m.BalanceCSVPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(m.BalanceCSVPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err := w.Write(balanceCSVHeader); err != nil {
+			return err
+		}
+	}
+	timestamp := now.UTC().Format(time.RFC3339)
+	for _, s := range streamers {
+		points, known, online := s.BalanceSnapshot()
+		if !known {
+			continue
+		}
+		record := []string{
+			timestamp,
+			s.Username,
+			strconv.Itoa(points),
+			strconv.FormatBool(online),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,50 @@
+package twitchchannelpointsminer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestAppendBalanceSampleWhileUpdating(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "balances.csv")
+	m := &Miner{BalanceCSVPath: path}
+	known := &entities.Streamer{Username: "known"}
+	known.StartSession(100)
+	unknown := &entities.Streamer{Username: "unknown"}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			known.SetChannelPoints(100 + i)
+			known.SetOnline(i%2 == 0)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if err := m.appendBalanceSample([]*entities.Streamer{known, unknown}, time.Unix(0, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 11 {
+		t.Fatalf("got %d lines, want a header and 10 samples", len(lines))
+	}
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line, "1970-01-01T00:00:00Z,known,") {
+			t.Errorf("unexpected sample %q", line)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package twitchchannelpointsminer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+// ? pointsBaseline is the balance a streamer had on the first run that saw it, for session_baseline "lifetime".
+type pointsBaseline struct {
+	Points int       `json:"points"`
+	Since  time.Time `json:"since"`
+}
+
+func lifetimeBaselinePath(username string) string {
+	return filepath.Join("cookies", username+"_baseline.json")
+}
+
+func (m *Miner) lifetimeBaselineEnabled() bool {
+	return strings.EqualFold(m.SessionBaseline, "lifetime")
+}
+
+// ? loadLifetimeBaseline reads the persisted baselines and adds streamers seen for the first time with their
+// ? startup balance, so they count from this run on. Streamers whose balance is still unknown are added on a later run.
+func (m *Miner) loadLifetimeBaseline(streamers []*entities.Streamer) {
+	if !m.lifetimeBaselineEnabled() {
+		return
+	}
+	path := lifetimeBaselinePath(m.Username)
+	baselines := make(map[string]pointsBaseline)
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(raw, &baselines)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		m.logger.Errorf("baseline file: %v; counting from this run", err)
+		baselines = make(map[string]pointsBaseline)
+	}
+	added := false
+	for _, s := range streamers {
+		key := strings.ToLower(s.Username)
+		if _, ok := baselines[key]; ok || !s.PointsInit {
+			continue
+		}
+		baselines[key] = pointsBaseline{Points: s.SessionStart, Since: m.startedAt}
+		added = true
+	}
+	if added {
+		if raw, err := json.MarshalIndent(baselines, "", "  "); err != nil {
+			m.logger.Errorf("baseline file: %v", err)
+		} else if err := writeFileAtomic(path, raw); err != nil {
+			m.logger.Errorf("baseline file: %v", err)
+		}
+	}
+	m.lifetimeBaseline = baselines
+}
+
+// ? summaryBaseline is the balance gains in the summary are measured from: the lifetime baseline when enabled, else the startup balance.
+func (m *Miner) summaryBaseline(s *entities.Streamer) int {
+	if b, ok := m.lifetimeBaseline[strings.ToLower(s.Username)]; ok {
+		return b.Points
+	}
+	return s.SessionStart
+}
+
+// ? baselineLabel names the baseline the summary uses, with the oldest lifetime start date.
+func (m *Miner) baselineLabel() string {
+	if len(m.lifetimeBaseline) == 0 {
+		return "this session"
+	}
+	var oldest time.Time
+	for _, b := range m.lifetimeBaseline {
+		if oldest.IsZero() || b.Since.Before(oldest) {
+			oldest = b.Since
+		}
+	}
+	return "since first run on " + oldest.Format("02/01/06")
+}
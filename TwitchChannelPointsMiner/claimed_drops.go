@@ -0,0 +1,80 @@
+package twitchchannelpointsminer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	classpkg "TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes"
+)
+
+// ? claimedDropRetention is how long a claimed drop ID is remembered; campaigns rarely run longer.
+const claimedDropRetention = 60 * 24 * time.Hour
+
+// ? claimedDropStore remembers which drop instances were already reported, across restarts, so a fresh inventory
+// ? scan does not log drops again that Twitch now answers with DROP_INSTANCE_ALREADY_CLAIMED.
+type claimedDropStore struct {
+	path string
+	mu   sync.Mutex
+	ids  map[string]time.Time
+}
+
+func claimedDropsPath(username string) string {
+	return filepath.Join("cookies", username+"_claimed_drops.json")
+}
+
+func loadClaimedDropStore(path string) (*claimedDropStore, error) {
+	store := &claimedDropStore{path: path, ids: make(map[string]time.Time)}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	if err := json.Unmarshal(raw, &store.ids); err != nil {
+		return store, err
+	}
+	cutoff := time.Now().Add(-claimedDropRetention)
+	for id, at := range store.ids {
+		if at.Before(cutoff) {
+			delete(store.ids, id)
+		}
+	}
+	return store, nil
+}
+
+// ? fresh returns the drops not reported before and records them. A nil store treats every drop as new.
+func (s *claimedDropStore) fresh(drops []classpkg.ClaimedDrop) ([]classpkg.ClaimedDrop, error) {
+	if s == nil || len(drops) == 0 {
+		return drops, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []classpkg.ClaimedDrop
+	now := time.Now()
+	for _, drop := range drops {
+		if drop.InstanceID != "" {
+			if _, seen := s.ids[drop.InstanceID]; seen {
+				continue
+			}
+			s.ids[drop.InstanceID] = now
+		}
+		out = append(out, drop)
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	return out, s.save()
+}
+
+func (s *claimedDropStore) save() error {
+	raw, err := json.Marshal(s.ids)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, raw)
+}
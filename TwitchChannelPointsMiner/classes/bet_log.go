@@ -0,0 +1,67 @@
+package classes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ? betLogEntry is one line of the bet log. A "bet" line is written when the stake is placed and a "result" line
+// ? with the same event_id when it resolves; a later result line for the same event supersedes the earlier one.
+type betLogEntry struct {
+	Type      string  `json:"type"`
+	Timestamp string  `json:"timestamp"`
+	EventID   string  `json:"event_id"`
+	Streamer  string  `json:"streamer"`
+	Title     string  `json:"title"`
+	Outcome   string  `json:"outcome"`
+	Stake     int     `json:"stake"`
+	Odds      float64 `json:"odds,omitempty"`
+	Strategy  string  `json:"strategy,omitempty"`
+	Result    string  `json:"result,omitempty"`
+	Gained    int     `json:"gained"`
+}
+
+// ? betLog appends entries to a JSONL file; the mutex keeps lines from concurrent prediction timers intact.
+type betLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newBetLog(path string) *betLog {
+	if path == "" {
+		return nil
+	}
+	return &betLog{path: path}
+}
+
+func (l *betLog) append(entry betLogEntry) error {
+	if l == nil {
+		return nil
+	}
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if dir := filepath.Dir(l.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,40 @@
+package classes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ? ErrCookiesLocked means another process holds the account's cookie lock, i.e. the account is already being mined.
+var ErrCookiesLocked = errors.New("cookies are in use by another instance")
+
+// ? lockCookies takes an advisory lock next to the cookie file and keeps it for the life of the process, so a second
+// ? instance of the same account refuses to start instead of overwriting the cookies and churning the other's session.
+// ? The lock file holds the owner's PID for the error message; the OS releases the lock when the process exits.
+func lockCookies(cookiesPath string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(cookiesPath), 0o755); err != nil {
+		return nil, err
+	}
+	path := cookiesPath + ".lock"
+	f, err := lockFile(path)
+	if errors.Is(err, ErrCookiesLocked) {
+		holder := ""
+		if raw, readErr := os.ReadFile(path); readErr == nil && strings.TrimSpace(string(raw)) != "" {
+			holder = fmt.Sprintf(" (pid %s)", strings.TrimSpace(string(raw)))
+		}
+		return nil, fmt.Errorf("%w%s: %s is held, so this account is already running; stop the other instance first", ErrCookiesLocked, holder, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	if f != nil {
+		if err := f.Truncate(0); err == nil {
+			_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+		}
+	}
+	return f, nil
+}
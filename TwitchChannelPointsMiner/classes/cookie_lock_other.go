@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package classes
+
+import "os"
+
+// ? lockFile is a no-op where no advisory lock is available; running one account twice is not detected there.
+func lockFile(path string) (*os.File, error) {
+	return nil, nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package classes
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ? errSharingViolation is ERROR_SHARING_VIOLATION, returned while another process has the file open.
+const errSharingViolation syscall.Errno = 32
+
+// ? lockFile opens the file without sharing, which Windows enforces until the handle is closed.
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errSharingViolation) {
+			return nil, ErrCookiesLocked
+		}
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), path), nil
+}
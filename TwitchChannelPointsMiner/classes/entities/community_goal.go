@@ -1,5 +1,11 @@
 package entities
 
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
 type CommunityGoal struct {
 	ID                               string
 	Title                            string
@@ -66,7 +72,65 @@ func intFromAny(v interface{}) int {
 		return int(n)
 	case float64:
 		return int(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return int(f)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return int(f)
 	default:
 		return 0
 	}
 }
+
+// ? SetCommunityGoals replaces the streamer's goals with the ones loaded from the channel points context.
+func (s *Streamer) SetCommunityGoals(goals map[string]*CommunityGoal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.communityGoals = goals
+}
+
+// ? SetCommunityGoal adds or replaces one goal, as a PubSub update reports it.
+func (s *Streamer) SetCommunityGoal(goal *CommunityGoal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.communityGoals == nil {
+		s.communityGoals = make(map[string]*CommunityGoal)
+	}
+	s.communityGoals[goal.ID] = goal
+}
+
+func (s *Streamer) DeleteCommunityGoal(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.communityGoals, id)
+}
+
+// ? CommunityGoal returns a copy of the goal, so the caller may keep it while PubSub replaces the original.
+func (s *Streamer) CommunityGoal(id string) (CommunityGoal, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	goal := s.communityGoals[id]
+	if goal == nil {
+		return CommunityGoal{}, false
+	}
+	return *goal, true
+}
+
+// ? CommunityGoalList returns copies of every goal.
+func (s *Streamer) CommunityGoalList() []CommunityGoal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	goals := make([]CommunityGoal, 0, len(s.communityGoals))
+	for _, goal := range s.communityGoals {
+		if goal != nil {
+			goals = append(goals, *goal)
+		}
+	}
+	return goals
+}
+
+// ? Active reports whether the goal still takes contributions.
+func (c *CommunityGoal) Active() bool {
+	return c.Status == "STARTED" && c.IsInStock
+}
@@ -0,0 +1,51 @@
+package entities
+
+import "strings"
+
+// ? History categories; Twitch reason_code values are folded into these by NormalizeReason.
+const (
+	ReasonWatch       = "WATCH"
+	ReasonWatchStreak = "WATCH_STREAK"
+	ReasonClaim       = "CLAIM"
+	ReasonRaid        = "RAID"
+	ReasonFollow      = "FOLLOW"
+	ReasonSubGift     = "SUB_GIFT"
+	ReasonPrediction  = "PREDICTION"
+	ReasonRefund      = "REFUND"
+	ReasonOther       = "OTHER"
+	// ? ReasonCommunityGoal records points spent on community goals; Twitch has no reason_code for it.
+	ReasonCommunityGoal = "COMMUNITY_GOAL"
+)
+
+var reasonAliases = map[string]string{
+	"WATCH":              ReasonWatch,
+	"WATCH_TIME":         ReasonWatch,
+	"WATCH_BONUS":        ReasonWatch,
+	"WATCH_MULTIPLIER":   ReasonWatch,
+	"WATCH_STREAK":       ReasonWatchStreak,
+	"CLAIM":              ReasonClaim,
+	"CLAIM_BONUS":        ReasonClaim,
+	"RAID":               ReasonRaid,
+	"RAID_BONUS":         ReasonRaid,
+	"FOLLOW":             ReasonFollow,
+	"FOLLOW_BONUS":       ReasonFollow,
+	"SUB_GIFT":           ReasonSubGift,
+	"GIFT_SUB":           ReasonSubGift,
+	"SUBSCRIPTION_GIFT":  ReasonSubGift,
+	"COMMUNITY_SUB_GIFT": ReasonSubGift,
+	"PREDICTION":         ReasonPrediction,
+	"REFUND":             ReasonRefund,
+}
+
+// ? NormalizeReason maps a raw reason_code to its history category.
+// ? Unknown non-empty codes are kept as-is so new Twitch reasons still show up in the summary; known reports false for them.
+func NormalizeReason(code string) (reason string, known bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || code == "<NIL>" {
+		return ReasonOther, false
+	}
+	if reason, ok := reasonAliases[code]; ok {
+		return reason, true
+	}
+	return code, false
+}
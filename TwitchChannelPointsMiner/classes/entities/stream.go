@@ -18,6 +18,7 @@ type Stream struct {
 	CampaignIDs  []string
 	ViewersCount int
 	SpadeURL     string
+	SpadeFetched time.Time
 	Payload      []map[string]interface{}
 
 	WatchStreakMissing bool
@@ -36,7 +37,9 @@ func NewStream() *Stream {
 	}
 }
 
-func (s *Stream) Update(broadcastID, title string, game map[string]interface{}, tags []map[string]interface{}, viewers int, dropID string) {
+// ? Update reports whether the game differs from the one seen on the previous update of a live stream.
+func (s *Stream) Update(broadcastID, title string, game map[string]interface{}, tags []map[string]interface{}, viewers int, dropID string) (gameChanged bool) {
+	gameChanged = !s.lastUpdate.IsZero() && gameID(s.Game) != gameID(game)
 	s.BroadcastID = broadcastID
 	s.Title = strings.TrimSpace(title)
 	s.Game = game
@@ -50,12 +53,23 @@ func (s *Stream) Update(broadcastID, title string, game map[string]interface{},
 		}
 	}
 	s.lastUpdate = time.Now()
+	return gameChanged
+}
+
+func gameID(game map[string]interface{}) string {
+	id, _ := game["id"].(string)
+	return id
 }
 
 func (s *Stream) UpdateRequired() bool {
 	return s.lastUpdate.IsZero() || time.Since(s.lastUpdate) >= 120*time.Second
 }
 
+// ? Invalidate forces the next UpdateStream call to refetch metadata and drop tags.
+func (s *Stream) Invalidate() {
+	s.lastUpdate = time.Time{}
+}
+
 func (s *Stream) UpdateMinuteWatched() {
 	if !s.lastMinuteUpdate.IsZero() {
 		s.MinuteWatched += time.Since(s.lastMinuteUpdate).Minutes()
@@ -81,10 +95,11 @@ func (s *Stream) EncodePayload() (map[string]string, error) {
 }
 
 func (s *Stream) String() string {
-	return fmt.Sprintf("%s (%s)", s.Title, s.gameName())
+	return fmt.Sprintf("%s (%s)", s.Title, s.GameName())
 }
 
-func (s *Stream) gameName() string {
+// ? GameName is the display name of the stream's category, empty when unknown.
+func (s *Stream) GameName() string {
 	if s.Game == nil {
 		return ""
 	}
@@ -93,3 +108,82 @@ func (s *Stream) gameName() string {
 	}
 	return ""
 }
+
+// ? The Streamer methods below guard the stream fields that PubSub and the refresher change while the minute watcher
+// ? reads them: the Stream pointer, Game, CampaignIDs and the refresh time.
+
+// ? EnsureStream returns the streamer's stream, creating it on first use.
+func (s *Streamer) EnsureStream() *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Stream == nil {
+		s.Stream = NewStream()
+	}
+	return s.Stream
+}
+
+// ? UpdateStream applies freshly loaded metadata; see Stream.Update.
+func (s *Streamer) UpdateStream(broadcastID, title string, game map[string]interface{}, tags []map[string]interface{}, viewers int, dropID string) (gameChanged bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Stream.Update(broadcastID, title, game, tags, viewers, dropID)
+}
+
+func (s *Streamer) StreamUpdateRequired() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Stream == nil || s.Stream.UpdateRequired()
+}
+
+// ? SetStreamGame records a game switch reported by PubSub and forces the next stream refresh. It reports false
+// ? when the stream was never loaded.
+func (s *Streamer) SetStreamGame(game map[string]interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Stream == nil {
+		return false
+	}
+	s.Stream.Game = game
+	s.Stream.Invalidate()
+	return true
+}
+
+// ? StreamGameName is Stream.GameName, empty when the stream was never loaded.
+func (s *Streamer) StreamGameName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.Stream == nil {
+		return ""
+	}
+	return s.Stream.GameName()
+}
+
+func (s *Streamer) SetCampaignIDs(ids []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Stream != nil {
+		s.Stream.CampaignIDs = ids
+	}
+}
+
+// ? SetCampaignIDsForGame stores ids only while id is still the stream's game, so a refresh that finishes
+// ? after a newer game switch does not overwrite it. It reports whether the ids were stored.
+func (s *Streamer) SetCampaignIDsForGame(id string, ids []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Stream == nil || gameID(s.Stream.Game) != id {
+		return false
+	}
+	s.Stream.CampaignIDs = ids
+	return true
+}
+
+// ? CampaignCount is how many drop campaigns the current game has, 0 when the stream was never loaded.
+func (s *Streamer) CampaignCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.Stream == nil {
+		return 0
+	}
+	return len(s.Stream.CampaignIDs)
+}
@@ -1,6 +1,11 @@
 package entities
 
-import "time"
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
 
 type FollowersOrder string
 
@@ -12,19 +17,28 @@ const (
 type Strategy string
 
 const (
-	StrategyMostVoted  Strategy = "MOST_VOTED"
-	StrategyHighOdds   Strategy = "HIGH_ODDS"
-	StrategyPercentage Strategy = "PERCENTAGE"
-	StrategySmartMoney Strategy = "SMART_MONEY"
-	StrategySmart      Strategy = "SMART"
-	StrategyNumber1    Strategy = "NUMBER_1"
-	StrategyNumber2    Strategy = "NUMBER_2"
-	StrategyNumber3    Strategy = "NUMBER_3"
-	StrategyNumber4    Strategy = "NUMBER_4"
-	StrategyNumber5    Strategy = "NUMBER_5"
-	StrategyNumber6    Strategy = "NUMBER_6"
-	StrategyNumber7    Strategy = "NUMBER_7"
-	StrategyNumber8    Strategy = "NUMBER_8"
+	StrategyMostVoted       Strategy = "MOST_VOTED"
+	StrategyHighOdds        Strategy = "HIGH_ODDS"
+	StrategyPercentage      Strategy = "PERCENTAGE"
+	StrategySmartMoney      Strategy = "SMART_MONEY"
+	StrategySmartMoneyRatio Strategy = "SMART_MONEY_RATIO"
+	StrategySmart           Strategy = "SMART"
+	StrategyNumber1         Strategy = "NUMBER_1"
+	StrategyNumber2         Strategy = "NUMBER_2"
+	StrategyNumber3         Strategy = "NUMBER_3"
+	StrategyNumber4         Strategy = "NUMBER_4"
+	StrategyNumber5         Strategy = "NUMBER_5"
+	StrategyNumber6         Strategy = "NUMBER_6"
+	StrategyNumber7         Strategy = "NUMBER_7"
+	StrategyNumber8         Strategy = "NUMBER_8"
+)
+
+// ? TiePrefer decides between outcomes SMART finds tied on odds.
+type TiePrefer string
+
+const (
+	TiePreferFirst TiePrefer = "FIRST"
+	TiePreferUsers TiePrefer = "USERS"
 )
 
 type DelayMode string
@@ -33,32 +47,129 @@ const (
 	DelayModeFromStart  DelayMode = "FROM_START"
 	DelayModeFromEnd    DelayMode = "FROM_END"
 	DelayModePercentage DelayMode = "PERCENTAGE"
+	// ? DelayModeLastMoment bets delay seconds before close, never less than minLastMomentMargin, on the latest pool data.
+	DelayModeLastMoment DelayMode = "LAST_MOMENT"
 )
 
 type BetSettings struct {
-	Strategy        Strategy  `json:"strategy,omitempty"`
-	Percentage      *int      `json:"percentage,omitempty"`
-	PercentageGap   *int      `json:"percentage_gap,omitempty"`
-	MaxPoints       *int      `json:"max_points,omitempty"`
-	MinimumPoints   *int      `json:"minimum_points,omitempty"`
-	StealthMode     *bool     `json:"stealth_mode,omitempty"`
-	FilterCondition *string   `json:"filter_condition,omitempty"`
-	Delay           *float64  `json:"delay,omitempty"`
-	DelayMode       DelayMode `json:"delay_mode,omitempty"`
+	Strategy           Strategy          `json:"strategy,omitempty"`
+	Percentage         *int              `json:"percentage,omitempty"`
+	PercentageGap      *int              `json:"percentage_gap,omitempty"`
+	SmartTiePrefer     TiePrefer         `json:"smart_tie_prefer,omitempty"`
+	MaxPoints          *int              `json:"max_points,omitempty"`
+	MinimumPoints      *int              `json:"minimum_points,omitempty"`
+	StealthMode        *bool             `json:"stealth_mode,omitempty"`
+	FilterCondition    *string           `json:"filter_condition,omitempty"`
+	Delay              *float64          `json:"delay,omitempty"`
+	DelayMode          DelayMode         `json:"delay_mode,omitempty"`
+	AllowSingleOutcome *bool             `json:"allow_single_outcome,omitempty"`
+	MinWindowForDelay  *float64          `json:"min_window_for_delay_seconds,omitempty"`
+	MaxBetsPerStream   *int              `json:"max_bets_per_stream,omitempty"`
+	MinOutcomeUsers    *int              `json:"min_outcome_users,omitempty"`
+	SkipUnaffordable   *bool             `json:"skip_unaffordable,omitempty"`
+	Momentum           *MomentumSettings `json:"momentum,omitempty"`
+	PercentageScale    *PercentageScale  `json:"percentage_scale,omitempty"`
+	// ? StrategyByCategory overrides Strategy for predictions whose category matches a key (case-insensitive).
+	StrategyByCategory map[string]Strategy `json:"strategy_by_category,omitempty"`
+}
+
+// ? StrategyFor returns the strategy mapped to category, or false when there is none.
+func (b BetSettings) StrategyFor(category string) (Strategy, bool) {
+	if category == "" {
+		return "", false
+	}
+	for key, strategy := range b.StrategyByCategory {
+		if strings.EqualFold(strings.TrimSpace(key), category) && strategy != "" {
+			return strategy, true
+		}
+	}
+	return "", false
+}
+
+// ? PercentageScale replaces Percentage with the tier matching the event's total pool ("pool", the default) or the
+// ? channel's viewer count ("viewers"): the tier with the highest Min not above the value wins.
+type PercentageScale struct {
+	By    string           `json:"by,omitempty"`
+	Tiers []PercentageTier `json:"tiers,omitempty"`
+}
+
+type PercentageTier struct {
+	Min        int `json:"min"`
+	Percentage int `json:"percentage"`
+}
+
+// ? Percentage returns the tier's percentage and the value it was picked by; ok is false when no tier applies,
+// ? including when scaling by viewers before the count is known.
+func (s *PercentageScale) Percentage(pool, viewers int) (percentage, value int, ok bool) {
+	if s == nil {
+		return 0, 0, false
+	}
+	value = pool
+	if s.ByViewers() {
+		if viewers <= 0 {
+			return 0, 0, false
+		}
+		value = viewers
+	}
+	best := -1
+	for _, tier := range s.Tiers {
+		if tier.Min <= value && tier.Min > best {
+			best = tier.Min
+			percentage = tier.Percentage
+			ok = true
+		}
+	}
+	return percentage, value, ok
+}
+
+func (s *PercentageScale) ByViewers() bool {
+	return strings.EqualFold(strings.TrimSpace(s.By), "viewers")
+}
+
+// ? MomentumSettings scales the next stake by OnWin after a won bet and by OnLoss after a lost one; nil or 1 leaves it unchanged.
+type MomentumSettings struct {
+	OnWin  *float64 `json:"on_win,omitempty"`
+	OnLoss *float64 `json:"on_loss,omitempty"`
+}
+
+// ? Factor returns the stake multiplier for the streamer's last settled result ("WIN" or "LOSE"), or 1.
+func (m *MomentumSettings) Factor(lastResult string) float64 {
+	if m == nil {
+		return 1
+	}
+	var factor *float64
+	switch lastResult {
+	case "WIN":
+		factor = m.OnWin
+	case "LOSE":
+		factor = m.OnLoss
+	}
+	if factor == nil || *factor <= 0 {
+		return 1
+	}
+	return *factor
 }
 
 type StreamerSettings struct {
-	MakePredictions bool        `json:"make_predictions"`
-	FollowRaid      bool        `json:"follow_raid"`
-	ClaimDrops      bool        `json:"claim_drops"`
-	ClaimMoments    bool        `json:"claim_moments"`
-	WatchStreak     bool        `json:"watch_streak"`
-	CommunityGoals  bool        `json:"community_goals"`
-	Bet             BetSettings `json:"bet"`
+	MakePredictions   bool        `json:"make_predictions"`
+	FollowRaid        bool        `json:"follow_raid"`
+	ClaimDrops        bool        `json:"claim_drops"`
+	ClaimMoments      bool        `json:"claim_moments"`
+	ClaimBonus        bool        `json:"claim_bonus"`
+	WatchStreak       bool        `json:"watch_streak"`
+	CommunityGoals    bool        `json:"community_goals"`
+	PointsReserve     int         `json:"points_reserve"`
+	BetOnlyIfWatching bool        `json:"bet_only_if_watching"`
+	HouseMoneyOnly    bool        `json:"house_money_only"`
+	MinViewersToWatch int         `json:"min_viewers_to_watch"`
+	NotifyTarget      string      `json:"notify_target,omitempty"`
+	NotifyEvents      []string    `json:"notify_events,omitempty"`
+	Bet               BetSettings `json:"bet"`
 }
 
 type Streamer struct {
 	Username          string                   `json:"username"`
+	DisplayName       string                   `json:"display_name"`
 	ChannelID         string                   `json:"channel_id"`
 	ChannelPoints     int                      `json:"channel_points"`
 	Settings          StreamerSettings         `json:"settings"`
@@ -72,7 +183,23 @@ type Streamer struct {
 	ActiveMultipliers []map[string]interface{} `json:"-"`
 	LastRaidID        string                   `json:"-"`
 	History           map[string]*HistoryEntry
-	CommunityGoals    map[string]*CommunityGoal `json:"-"`
+	WatchedSession    time.Duration `json:"-"`
+	SessionStart      int           `json:"-"`
+	RaidsJoined       int           `json:"-"`
+	TotalWagered      int           `json:"-"`
+	PredictionNet     int           `json:"-"`
+	LastBetResult     string        `json:"-"`
+	WatchAwards       int           `json:"-"`
+	WatchAwardPoints  int           `json:"-"`
+	FollowRecency     int           `json:"-"`
+	FollowedAt        time.Time     `json:"-"`
+	lastWatchCredit   time.Time
+	betsBroadcastID   string
+	betsThisStream    int
+	communityGoals    map[string]*CommunityGoal
+	// ? mu guards ChannelPoints, IsOnline, PointsInit, the community goals and the stream fields listed in stream.go,
+	// ? which timer, sampler and watcher goroutines read besides PubSub and the refresher.
+	mu sync.RWMutex
 }
 
 type HistoryEntry struct {
@@ -80,10 +207,135 @@ type HistoryEntry struct {
 	Amount int
 }
 
+// ? maxWatchCreditGap bounds how much time one minute-watched success may credit, so offline gaps are not counted.
+const maxWatchCreditGap = 2 * time.Minute
+
+// ? CreditWatchTime adds the time since the previous successful minute-watched event to WatchedSession.
+// ? It lives on Streamer rather than Stream so the session total survives stream resets.
+func (s *Streamer) CreditWatchTime(now time.Time) {
+	if !s.lastWatchCredit.IsZero() {
+		if gap := now.Sub(s.lastWatchCredit); gap > 0 && gap <= maxWatchCreditGap {
+			s.WatchedSession += gap
+		}
+	}
+	s.lastWatchCredit = now
+}
+
+// ? watchAwardWindow is how recent a minute-watched success must be for a WATCH award to be credited to the miner;
+// ? awards outside it come from watching elsewhere and would skew the rate.
+const watchAwardWindow = 10 * time.Minute
+
+// ? minWatchRateSample is how much watch time WatchRate needs before the per-hour figure means anything.
+const minWatchRateSample = 15 * time.Minute
+
+// ? RecordWatchAward counts a WATCH points award toward the session's observed watch rate.
+func (s *Streamer) RecordWatchAward(points int, now time.Time) {
+	if points <= 0 || !s.WatchedWithin(watchAwardWindow, now) {
+		return
+	}
+	s.WatchAwards++
+	s.WatchAwardPoints += points
+}
+
+// ? WatchRate is the observed payout of watching this session: the average WATCH award and the points earned per
+// ? hour of credited watch time. ok is false until an award arrived and enough time was watched.
+func (s *Streamer) WatchRate() (perAward, perHour float64, ok bool) {
+	if s.WatchAwards == 0 || s.WatchedSession < minWatchRateSample {
+		return 0, 0, false
+	}
+	perAward = float64(s.WatchAwardPoints) / float64(s.WatchAwards)
+	perHour = float64(s.WatchAwardPoints) / s.WatchedSession.Hours()
+	return perAward, perHour, true
+}
+
+// ? BetsThisStream counts bets placed during the given broadcast; a new broadcast ID starts the count over.
+func (s *Streamer) BetsThisStream(broadcastID string) int {
+	if s.betsBroadcastID != broadcastID {
+		return 0
+	}
+	return s.betsThisStream
+}
+
+func (s *Streamer) RecordBet(broadcastID string) {
+	if s.betsBroadcastID != broadcastID {
+		s.betsBroadcastID = broadcastID
+		s.betsThisStream = 0
+	}
+	s.betsThisStream++
+}
+
+// ? PredictionROI is net prediction gain over points wagered on settled bets; ok is false before any bet settles.
+func (s *Streamer) PredictionROI() (roi float64, ok bool) {
+	if s.TotalWagered <= 0 {
+		return 0, false
+	}
+	return float64(s.PredictionNet) / float64(s.TotalWagered), true
+}
+
+// ? SessionProfit is the balance gained since the session started; it is what house_money_only lets bets use.
+func (s *Streamer) SessionProfit() int {
+	return s.ChannelPoints - s.SessionStart
+}
+
+// ? WatchedWithin reports whether a minute-watched event succeeded in the last d.
+func (s *Streamer) WatchedWithin(d time.Duration, now time.Time) bool {
+	return !s.lastWatchCredit.IsZero() && now.Sub(s.lastWatchCredit) <= d
+}
+
 func (s *Streamer) HasActiveMultipliers() bool {
 	return len(s.ActiveMultipliers) > 0
 }
 
+// ? SetChannelPoints stores a balance reported by Twitch.
+func (s *Streamer) SetChannelPoints(points int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ChannelPoints = points
+}
+
+// ? StartSession records the first known balance as the session baseline. It is normally the startup load, but a
+// ? streamer whose load failed gets it from the first refresh or PubSub update, so gains are not measured from 0.
+func (s *Streamer) StartSession(balance int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.PointsInit = true
+	s.SessionStart = balance
+}
+
+func (s *Streamer) SetOnline(online bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.IsOnline = online
+}
+
+// ? BalanceSnapshot reads the balance, whether it is known yet, and the presence in one consistent view.
+func (s *Streamer) BalanceSnapshot() (points int, known, online bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ChannelPoints, s.PointsInit, s.IsOnline
+}
+
+// ? DebitChannelPoints subtracts points spent on the channel, stopping at 0.
+func (s *Streamer) DebitChannelPoints(amount int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ChannelPoints -= amount
+	if s.ChannelPoints < 0 {
+		s.ChannelPoints = 0
+	}
+}
+
+// ? SpendablePoints is the balance above PointsReserve that bets and goal contributions may use.
+func (s *Streamer) SpendablePoints() int {
+	s.mu.RLock()
+	spendable := s.ChannelPoints - s.Settings.PointsReserve
+	s.mu.RUnlock()
+	if spendable < 0 {
+		return 0
+	}
+	return spendable
+}
+
 func (s *Streamer) TotalMultiplier() float64 {
 	total := 0.0
 	for _, mult := range s.ActiveMultipliers {
@@ -100,6 +352,22 @@ func (s *Streamer) TotalMultiplier() float64 {
 	return total
 }
 
+// ? Twitch pays 10 points per 5 watched minutes plus a 50 point bonus chest roughly every 15 minutes; multipliers scale both.
+const (
+	baseWatchPointsPerMinute = 10.0 / 5
+	bonusPointsPerMinute     = 50.0 / 15
+)
+
+// ? PointsPerMinuteEstimate is the expected yield of a watch slot given the channel's active multipliers.
+func (s *Streamer) PointsPerMinuteEstimate() float64 {
+	return (baseWatchPointsPerMinute + bonusPointsPerMinute) * (1 + s.TotalMultiplier())
+}
+
+// ? SkipsDelay reports whether a prediction window is shorter than MinWindowForDelay, so the bet is placed right away.
+func (b BetSettings) SkipsDelay(predictionWindow float64) bool {
+	return b.MinWindowForDelay != nil && predictionWindow < *b.MinWindowForDelay
+}
+
 func (s *Streamer) PredictionWindowSeconds(predictionWindow float64) float64 {
 	delay := 0.0
 	if s.Settings.Bet.Delay != nil {
@@ -118,11 +386,20 @@ func (s *Streamer) PredictionWindowSeconds(predictionWindow float64) float64 {
 		return 0
 	case DelayModePercentage:
 		return predictionWindow * delay
+	case DelayModeLastMoment:
+		margin := math.Max(delay, minLastMomentMargin)
+		return math.Max(predictionWindow-margin, 0)
 	default:
 		return predictionWindow
 	}
 }
 
+// ? minLastMomentMargin leaves time for the bet request to reach Twitch before the window closes.
+const minLastMomentMargin = 1.0
+
+// ? MinBetPoints is the smallest stake Twitch accepts.
+const MinBetPoints = 10
+
 func (b *BetSettings) Default() {
 	if b.Strategy == "" {
 		b.Strategy = StrategySmart
@@ -135,7 +412,10 @@ func (b *BetSettings) Default() {
 		v := 20
 		b.PercentageGap = &v
 	}
-	if b.MaxPoints == nil {
+	if b.SmartTiePrefer == "" {
+		b.SmartTiePrefer = TiePreferFirst
+	}
+	if b.MaxPoints == nil || *b.MaxPoints <= 0 {
 		v := 50000
 		b.MaxPoints = &v
 	}
@@ -152,8 +432,27 @@ func (b *BetSettings) Default() {
 	}
 	if b.Delay == nil {
 		d := 6.0
+		if b.DelayMode == DelayModeLastMoment {
+			d = 2.0
+		}
 		b.Delay = &d
 	}
+	if b.AllowSingleOutcome == nil {
+		v := false
+		b.AllowSingleOutcome = &v
+	}
+	if b.MaxBetsPerStream == nil {
+		v := 0
+		b.MaxBetsPerStream = &v
+	}
+	if b.MinOutcomeUsers == nil {
+		v := 0
+		b.MinOutcomeUsers = &v
+	}
+	if b.SkipUnaffordable == nil {
+		v := true
+		b.SkipUnaffordable = &v
+	}
 }
 
 func (s *StreamerSettings) Default() {
@@ -0,0 +1,66 @@
+package classes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ? debugTransport logs method, host, path, GQL operation names, status and latency of every request.
+// ? Headers, query strings and bodies are never logged, so tokens and cookies stay out of the log.
+type debugTransport struct {
+	base   http.RoundTripper
+	logger Logger
+}
+
+func (d *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := req.URL.Host + req.URL.Path
+	if ops := gqlOperationNames(req); ops != "" {
+		target += " " + ops
+	}
+	start := time.Now()
+	resp, err := d.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		d.logger.Printf("HTTP %s %s -> error after %s: %v", req.Method, target, elapsed, err)
+		return resp, err
+	}
+	d.logger.Printf("HTTP %s %s -> %d (%s)", req.Method, target, resp.StatusCode, elapsed)
+	return resp, nil
+}
+
+// ? gqlOperationNames reads operationName from a single or batched GQL body through GetBody, leaving the request intact.
+func gqlOperationNames(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil || !strings.HasSuffix(req.URL.Host, "gql.twitch.tv") {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	type operation struct {
+		OperationName string `json:"operationName"`
+	}
+	var batch []operation
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		var single operation
+		if json.Unmarshal(raw, &single) != nil {
+			return ""
+		}
+		batch = []operation{single}
+	}
+	names := make([]string, 0, len(batch))
+	for _, op := range batch {
+		if op.OperationName != "" {
+			names = append(names, op.OperationName)
+		}
+	}
+	return strings.Join(names, ",")
+}
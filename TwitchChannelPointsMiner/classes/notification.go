@@ -0,0 +1,28 @@
+package classes
+
+const (
+	NotifyOnline           = "online"
+	NotifyOffline          = "offline"
+	NotifyBet              = "bet"
+	NotifyPredictionResult = "prediction_result"
+	NotifyDropClaim        = "drop_claim"
+	NotifyDropExpiry       = "drop_expiry"
+)
+
+// ? Notification is the data exposed to notify templates; fields unrelated to Kind stay zero.
+// ? Target names the notify.targets route to deliver to; empty uses the global destinations.
+type Notification struct {
+	Kind     string
+	Streamer string
+	Points   int
+	Title    string
+	Outcome  string
+	Amount   int
+	Gained   int
+	Result   string
+	Reward   string
+	Campaign string
+	Progress string
+	Expires  string
+	Target   string
+}
@@ -1,6 +1,7 @@
 package classes
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -20,34 +21,62 @@ type PredictionOutcome struct {
 	PercentageUsers float64
 	Odds            float64
 	OddsPercentage  float64
+	// ? ignored marks outcomes below min_outcome_users; the data-driven strategies never pick them.
+	ignored bool
 }
 
 type PredictionDecision struct {
 	Choice    int
 	OutcomeID string
 	Amount    int
+	Rationale string
+	// ? Strategy is the strategy that picked the outcome, after strategy_by_category.
+	Strategy entities.Strategy
+	// ? EventCappedFrom is the stake before the event's per-user limit reduced it, 0 when the limit didn't apply.
+	EventCappedFrom int
 }
 
 type PredictionEvent struct {
-	Streamer      *entities.Streamer
-	EventID       string
-	Title         string
-	Status        string
-	CreatedAt     time.Time
-	WindowSeconds float64
-	Outcomes      []PredictionOutcome
-	Decision      PredictionDecision
-	BetPlaced     bool
-	BetConfirmed  bool
-	ResultType    string
-	ResultString  string
-}
-
-func NewPredictionEvent(streamer *entities.Streamer, event map[string]interface{}) *PredictionEvent {
+	Streamer        *entities.Streamer
+	EventID         string
+	Title           string
+	Category        string
+	Status          string
+	CreatedAt       time.Time
+	WindowSeconds   float64
+	MaxPerUser      int
+	Outcomes        []PredictionOutcome
+	Decision        PredictionDecision
+	BetPlaced       bool
+	BetConfirmed    bool
+	PoolIncludesBet bool
+	ResultType      string
+	ResultString    string
+	timer           *time.Timer
+	resultTentative bool
+	resultHistory   []historyDelta
+	payloadOutcomes int
+	outcomesAt      time.Time
+	settledPlaced   int
+	settledGained   int
+	settledAt       time.Time
+}
+
+// ? historyDelta is one history change made for a prediction result, kept so the result can be reversed.
+type historyDelta struct {
+	reason string
+	amount int
+}
+
+// ? NewPredictionEvent rejects events without an id or without any usable outcome so no bet timer is scheduled for them.
+func NewPredictionEvent(streamer *entities.Streamer, event map[string]interface{}) (*PredictionEvent, error) {
 	if streamer == nil || event == nil {
-		return nil
+		return nil, errors.New("missing streamer or event payload")
 	}
 	eventID, _ := event["id"].(string)
+	if eventID == "" {
+		return nil, errors.New("missing event id")
+	}
 	title, _ := event["title"].(string)
 	status := strings.ToUpper(stringOrDefault(event["status"]))
 	window := float64(fromFloat(event["prediction_window_seconds"]))
@@ -61,16 +90,42 @@ func NewPredictionEvent(streamer *entities.Streamer, event map[string]interface{
 		Streamer:      streamer,
 		EventID:       eventID,
 		Title:         strings.TrimSpace(title),
+		Category:      predictionCategory(streamer, event),
 		Status:        status,
 		CreatedAt:     created,
 		WindowSeconds: window,
+		MaxPerUser:    maxPointsPerUser(event),
 		BetPlaced:     false,
 	}
 	rawOutcomes, _ := event["outcomes"].([]interface{})
 	pe.UpdateOutcomes(rawOutcomes)
-	return pe
+	if len(pe.Outcomes) == 0 {
+		return nil, fmt.Errorf("no valid outcomes (%d in payload)", len(rawOutcomes))
+	}
+	return pe, nil
 }
 
+// ? predictionCategory reads a category when the payload carries one, and otherwise uses the game being streamed.
+func predictionCategory(streamer *entities.Streamer, event map[string]interface{}) string {
+	for _, key := range []string{"category", "type"} {
+		if v, ok := event[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return streamer.StreamGameName()
+}
+
+// ? maxPointsPerUser reads the streamer-set stake limit when the payload carries one; 0 means no limit.
+func maxPointsPerUser(event map[string]interface{}) int {
+	for _, key := range []string{"max_points_per_user", "maximum_points_per_user", "maxPointsPerUser"} {
+		if v := int(fromFloat(event[key])); v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// ? UpdateOutcomes ignores outcomes without an id and keeps the previous snapshot when a payload has none usable.
 func (p *PredictionEvent) UpdateOutcomes(outcomes []interface{}) {
 	parsed := make([]PredictionOutcome, 0, len(outcomes))
 	totalUsers := 0
@@ -87,6 +142,9 @@ func (p *PredictionEvent) UpdateOutcomes(outcomes []interface{}) {
 			TotalUsers:  int(fromFloat(oc["total_users"])),
 			TotalPoints: int(fromFloat(oc["total_points"])),
 		}
+		if outcome.ID == "" {
+			continue
+		}
 		if topPredictors, ok := oc["top_predictors"].([]interface{}); ok && len(topPredictors) > 0 {
 			if first, ok := topPredictors[0].(map[string]interface{}); ok {
 				outcome.TopPoints = int(fromFloat(first["points"]))
@@ -96,6 +154,11 @@ func (p *PredictionEvent) UpdateOutcomes(outcomes []interface{}) {
 		totalUsers += outcome.TotalUsers
 		totalPoints += outcome.TotalPoints
 	}
+	if len(parsed) == 0 {
+		return
+	}
+	p.payloadOutcomes = len(outcomes)
+	p.outcomesAt = time.Now()
 	for i := range parsed {
 		if totalUsers > 0 {
 			parsed[i].PercentageUsers = (float64(parsed[i].TotalUsers) * 100) / float64(totalUsers)
@@ -110,6 +173,45 @@ func (p *PredictionEvent) UpdateOutcomes(outcomes []interface{}) {
 	p.Outcomes = parsed
 }
 
+// ? HasEnoughOutcomes requires two outcomes, or one when bet.allow_single_outcome is enabled.
+func (p *PredictionEvent) HasEnoughOutcomes() bool {
+	minimum := 2
+	if p.Streamer != nil && p.Streamer.Settings.Bet.AllowSingleOutcome != nil && *p.Streamer.Settings.Bet.AllowSingleOutcome {
+		minimum = 1
+	}
+	return len(p.Outcomes) >= minimum
+}
+
+// ? MarketFormed rejects events whose payload lists several outcomes of which fewer than two are populated yet,
+// ? so Decide does not bet blindly into the one that is. A NUMBER_n strategy only needs its own outcome.
+func (p *PredictionEvent) MarketFormed() (bool, string) {
+	populated := len(p.Outcomes)
+	if populated >= 2 || p.payloadOutcomes <= populated {
+		return true, ""
+	}
+	if strategy := p.strategy(); strings.HasPrefix(string(strategy), "NUMBER_") && fixedOutcomeIndex(strategy) < populated {
+		return true, ""
+	}
+	return false, fmt.Sprintf("only %d of %d outcomes populated", populated, p.payloadOutcomes)
+}
+
+// ? strategy is the bet strategy for this event, after strategy_by_category.
+func (p *PredictionEvent) strategy() entities.Strategy {
+	settings := p.Streamer.Settings.Bet
+	if strategy, ok := settings.StrategyFor(p.Category); ok {
+		return strategy
+	}
+	return settings.Strategy
+}
+
+func (p *PredictionEvent) totalPoints() int {
+	total := 0
+	for _, o := range p.Outcomes {
+		total += o.TotalPoints
+	}
+	return total
+}
+
 func (p *PredictionEvent) ClosingAfter(now time.Time) time.Duration {
 	elapsed := now.Sub(p.CreatedAt).Seconds()
 	remaining := p.WindowSeconds - elapsed
@@ -125,9 +227,15 @@ func (p *PredictionEvent) Decide(balance int) PredictionDecision {
 		return decision
 	}
 	settings := p.Streamer.Settings.Bet
+	settings.Strategy = p.strategy()
+	decision.Strategy = settings.Strategy
 
-	choice := selectOutcome(p.Outcomes, settings)
+	choice, rationale := selectOutcome(p.Outcomes, settings)
+	if decision.Strategy != p.Streamer.Settings.Bet.Strategy {
+		rationale = fmt.Sprintf("%s; %s for category %q", rationale, decision.Strategy, p.Category)
+	}
 	if choice < 0 || choice >= len(p.Outcomes) {
+		decision.Rationale = rationale
 		return decision
 	}
 
@@ -135,10 +243,32 @@ func (p *PredictionEvent) Decide(balance int) PredictionDecision {
 	if settings.Percentage != nil {
 		percentage = *settings.Percentage
 	}
+	viewers := 0
+	if p.Streamer.Stream != nil {
+		viewers = p.Streamer.Stream.ViewersCount
+	}
+	if scaled, value, ok := settings.PercentageScale.Percentage(p.totalPoints(), viewers); ok {
+		unit := "points in the pool"
+		if settings.PercentageScale.ByViewers() {
+			unit = "viewers"
+		}
+		rationale = fmt.Sprintf("%s; %d%% for %d %s", rationale, scaled, value, unit)
+		percentage = scaled
+	}
 	amount := int(float64(balance) * (float64(percentage) / 100))
+	if factor := settings.Momentum.Factor(p.Streamer.LastBetResult); factor != 1 {
+		// ? applied before max_points and the event limit so those still cap the scaled stake
+		amount = int(float64(amount) * factor)
+		rationale = fmt.Sprintf("%s; momentum x%s after %s", rationale, formatFloat(factor), p.Streamer.LastBetResult)
+	}
 	if settings.MaxPoints != nil && amount > *settings.MaxPoints {
 		amount = *settings.MaxPoints
 	}
+	cappedFrom := 0
+	if p.MaxPerUser > 0 && amount > p.MaxPerUser {
+		cappedFrom = amount
+		amount = p.MaxPerUser
+	}
 	if amount > balance {
 		amount = balance
 	}
@@ -151,15 +281,20 @@ func (p *PredictionEvent) Decide(balance int) PredictionDecision {
 	if amount < 10 {
 		if settings.MaxPoints != nil && *settings.MaxPoints < 10 {
 			amount = *settings.MaxPoints
+		} else if p.MaxPerUser > 0 && p.MaxPerUser < 10 {
+			amount = p.MaxPerUser
 		} else if balance >= 10 {
 			amount = 10
 		}
 	}
 
 	decision = PredictionDecision{
-		Choice:    choice,
-		OutcomeID: p.Outcomes[choice].ID,
-		Amount:    amount,
+		Choice:          choice,
+		OutcomeID:       p.Outcomes[choice].ID,
+		Amount:          amount,
+		Rationale:       rationale,
+		Strategy:        decision.Strategy,
+		EventCappedFrom: cappedFrom,
 	}
 	p.Decision = decision
 	p.BetPlaced = amount > 0
@@ -168,13 +303,7 @@ func (p *PredictionEvent) Decide(balance int) PredictionDecision {
 
 func (p *PredictionEvent) ParseResult(result map[string]interface{}) (gained, placed, won int, resultType, resultString string) {
 	resultType = strings.ToUpper(stringOrDefault(result["type"]))
-	placed = p.Decision.Amount
-	won = int(fromFloat(result["points_won"]))
-	if resultType == "REFUND" {
-		placed = 0
-		won = 0
-	}
-	gained = won - placed
+	gained, placed, won = p.resultGain(result)
 	p.ResultType = resultType
 	action := "Gained"
 	switch resultType {
@@ -192,6 +321,16 @@ func (p *PredictionEvent) ParseResult(result map[string]interface{}) (gained, pl
 	return
 }
 
+// ? resultGain is the points a result moves: the stake placed, the payout won and the net gained. A refund moves none.
+func (p *PredictionEvent) resultGain(result map[string]interface{}) (gained, placed, won int) {
+	if strings.EqualFold(stringOrDefault(result["type"]), "REFUND") {
+		return 0, 0, 0
+	}
+	placed = p.Decision.Amount
+	won = int(fromFloat(result["points_won"]))
+	return won - placed, placed, won
+}
+
 func (p *PredictionEvent) String() string {
 	if p.Streamer != nil && p.Streamer.Username != "" {
 		return fmt.Sprintf("EventPrediction: %s - %s", p.Streamer.Username, p.Title)
@@ -282,10 +421,38 @@ func trimZeros(val string) string {
 	return val
 }
 
-func selectOutcome(outcomes []PredictionOutcome, settings entities.BetSettings) int {
+// ? selectOutcome returns the chosen outcome index together with a short human-readable reason for it.
+func selectOutcome(outcomes []PredictionOutcome, settings entities.BetSettings) (int, string) {
 	if len(outcomes) == 0 {
-		return -1
+		return -1, "no outcomes"
+	}
+	minUsers := 0
+	if settings.MinOutcomeUsers != nil {
+		minUsers = *settings.MinOutcomeUsers
+	}
+	if minUsers <= 0 {
+		return pickOutcome(outcomes, settings)
+	}
+	marked := append([]PredictionOutcome(nil), outcomes...)
+	ignored := 0
+	for i := range marked {
+		if marked[i].TotalUsers < minUsers {
+			marked[i].ignored = true
+			ignored++
+		}
 	}
+	choice, rationale := pickOutcome(marked, settings)
+	switch {
+	case ignored == len(marked):
+		// ? pickOutcome falls back to every outcome when all are marked
+		rationale += fmt.Sprintf(" (every outcome has fewer than %d users, min_outcome_users not applied)", minUsers)
+	case ignored > 0:
+		rationale += fmt.Sprintf(" (ignored %d outcome(s) with fewer than %d users)", ignored, minUsers)
+	}
+	return choice, rationale
+}
+
+func pickOutcome(outcomes []PredictionOutcome, settings entities.BetSettings) (int, string) {
 	strategy := settings.Strategy
 	if strategy == "" {
 		strategy = entities.StrategySmart
@@ -293,75 +460,144 @@ func selectOutcome(outcomes []PredictionOutcome, settings entities.BetSettings)
 
 	switch strategy {
 	case entities.StrategyMostVoted:
-		return maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TotalUsers) })
+		choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TotalUsers) })
+		return choice, fmt.Sprintf("MOST_VOTED: outcome %s has the most users (%d)", choiceLabel(choice), outcomes[choice].TotalUsers)
 	case entities.StrategyHighOdds:
-		return maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+		choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+		return choice, fmt.Sprintf("HIGH_ODDS: outcome %s has the highest odds (%s)", choiceLabel(choice), formatFloat(outcomes[choice].Odds))
 	case entities.StrategyPercentage:
-		return maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.OddsPercentage })
+		choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.OddsPercentage })
+		return choice, fmt.Sprintf("PERCENTAGE: outcome %s has the highest odds percentage (%s%%)", choiceLabel(choice), formatFloat(outcomes[choice].OddsPercentage))
 	case entities.StrategySmartMoney:
-		return maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TopPoints) })
-	case entities.StrategyNumber1:
-		if len(outcomes) > 0 {
-			return 0
+		choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TopPoints) })
+		return choice, fmt.Sprintf("SMART_MONEY: outcome %s has the biggest top predictor (%s points)", choiceLabel(choice), formatNumber(outcomes[choice].TopPoints))
+	case entities.StrategySmartMoneyRatio:
+		// ? A whale holding most of a small pool is a stronger signal than a big bet lost in a crowded one.
+		choice, ratio := topPointsRatioIndex(outcomes)
+		if choice < 0 {
+			fallback := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+			return fallback, fmt.Sprintf("SMART_MONEY_RATIO: every pool is empty, chose highest odds outcome %s", choiceLabel(fallback))
 		}
-	case entities.StrategyNumber2:
-		if len(outcomes) > 1 {
-			return 1
-		}
-	case entities.StrategyNumber3:
-		if len(outcomes) > 2 {
-			return 2
-		}
-	case entities.StrategyNumber4:
-		if len(outcomes) > 3 {
-			return 3
-		}
-	case entities.StrategyNumber5:
-		if len(outcomes) > 4 {
-			return 4
-		}
-	case entities.StrategyNumber6:
-		if len(outcomes) > 5 {
-			return 5
-		}
-	case entities.StrategyNumber7:
-		if len(outcomes) > 6 {
-			return 6
-		}
-	case entities.StrategyNumber8:
-		if len(outcomes) > 7 {
-			return 7
+		return choice, fmt.Sprintf("SMART_MONEY_RATIO: top predictor holds %s%% of outcome %s's pool", formatFloat(ratio*100), choiceLabel(choice))
+	case entities.StrategyNumber1, entities.StrategyNumber2, entities.StrategyNumber3, entities.StrategyNumber4,
+		entities.StrategyNumber5, entities.StrategyNumber6, entities.StrategyNumber7, entities.StrategyNumber8:
+		choice := fixedOutcomeIndex(strategy)
+		if choice < len(outcomes) {
+			return choice, fmt.Sprintf("%s: fixed outcome %s", strategy, choiceLabel(choice))
 		}
+		fallback := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+		return fallback, fmt.Sprintf("%s: only %d outcome(s), fell back to highest odds outcome %s", strategy, len(outcomes), choiceLabel(fallback))
 	case entities.StrategySmart:
 		gap := 20
 		if settings.PercentageGap != nil {
 			gap = *settings.PercentageGap
 		}
-		percents := append([]PredictionOutcome(nil), outcomes...)
+		percents := eligibleOutcomes(outcomes)
 		sort.SliceStable(percents, func(i, j int) bool {
 			return percents[i].PercentageUsers > percents[j].PercentageUsers
 		})
 		if len(percents) >= 2 {
-			if math.Abs(percents[0].PercentageUsers-percents[1].PercentageUsers) < float64(gap) {
-				return maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+			diff := math.Abs(percents[0].PercentageUsers - percents[1].PercentageUsers)
+			if diff < float64(gap) {
+				choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+				rationale := fmt.Sprintf("SMART: user gap %s%% < %d%% threshold, chose highest odds outcome %s (%s)", formatFloat(diff), gap, choiceLabel(choice), formatFloat(outcomes[choice].Odds))
+				if strings.EqualFold(string(settings.SmartTiePrefer), string(entities.TiePreferUsers)) {
+					if tied := mostUsersAtOdds(outcomes, choice); tied != choice {
+						choice = tied
+						rationale = fmt.Sprintf("SMART: user gap %s%% < %d%% threshold, outcomes tied on highest odds (%s), chose %s with the most users (%d)", formatFloat(diff), gap, formatFloat(outcomes[choice].Odds), choiceLabel(choice), outcomes[choice].TotalUsers)
+					}
+				}
+				return choice, rationale
 			}
+			choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TotalUsers) })
+			return choice, fmt.Sprintf("SMART: user gap %s%% >= %d%% threshold, chose most voted outcome %s (%s%% of users)", formatFloat(diff), gap, choiceLabel(choice), formatFloat(outcomes[choice].PercentageUsers))
 		}
-		return maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TotalUsers) })
+		choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return float64(o.TotalUsers) })
+		return choice, fmt.Sprintf("SMART: single outcome %s", choiceLabel(choice))
 	}
 
-	return maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+	choice := maxIndex(outcomes, func(o PredictionOutcome) float64 { return o.Odds })
+	return choice, fmt.Sprintf("unknown strategy %q, chose highest odds outcome %s", strategy, choiceLabel(choice))
 }
 
+func fixedOutcomeIndex(strategy entities.Strategy) int {
+	switch strategy {
+	case entities.StrategyNumber2:
+		return 1
+	case entities.StrategyNumber3:
+		return 2
+	case entities.StrategyNumber4:
+		return 3
+	case entities.StrategyNumber5:
+		return 4
+	case entities.StrategyNumber6:
+		return 5
+	case entities.StrategyNumber7:
+		return 6
+	case entities.StrategyNumber8:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// ? topPointsRatioIndex returns the outcome whose top predictor holds the largest share of its pool, skipping empty pools.
+func topPointsRatioIndex(outcomes []PredictionOutcome) (int, float64) {
+	best, bestRatio := -1, 0.0
+	for i, o := range outcomes {
+		if o.TotalPoints <= 0 || o.ignored {
+			continue
+		}
+		ratio := float64(o.TopPoints) / float64(o.TotalPoints)
+		if best < 0 || ratio > bestRatio {
+			best, bestRatio = i, ratio
+		}
+	}
+	return best, bestRatio
+}
+
+// ? eligibleOutcomes returns the outcomes not marked ignored, or all of them when every outcome is.
+func eligibleOutcomes(outcomes []PredictionOutcome) []PredictionOutcome {
+	eligible := make([]PredictionOutcome, 0, len(outcomes))
+	for _, o := range outcomes {
+		if !o.ignored {
+			eligible = append(eligible, o)
+		}
+	}
+	if len(eligible) == 0 {
+		return append(eligible, outcomes...)
+	}
+	return eligible
+}
+
+// ? mostUsersAtOdds returns the outcome with the most users among those sharing best's odds and eligibility;
+// ? equal user counts keep the earlier outcome.
+func mostUsersAtOdds(outcomes []PredictionOutcome, best int) int {
+	choice := best
+	for i, o := range outcomes {
+		if o.ignored != outcomes[best].ignored || o.Odds != outcomes[best].Odds {
+			continue
+		}
+		if o.TotalUsers > outcomes[choice].TotalUsers {
+			choice = i
+		}
+	}
+	return choice
+}
+
+// ? maxIndex returns the index of the highest value, skipping ignored outcomes unless all of them are.
 func maxIndex(outcomes []PredictionOutcome, value func(PredictionOutcome) float64) int {
-	if len(outcomes) == 0 {
-		return -1
-	}
-	best := 0
-	bestVal := value(outcomes[0])
-	for i := 1; i < len(outcomes); i++ {
-		if v := value(outcomes[i]); v > bestVal {
-			best = i
-			bestVal = v
+	best := -1
+	bestVal := 0.0
+	for pass := 0; pass < 2 && best < 0; pass++ {
+		for i, o := range outcomes {
+			if pass == 0 && o.ignored {
+				continue
+			}
+			if v := value(o); best < 0 || v > bestVal {
+				best = i
+				bestVal = v
+			}
 		}
 	}
 	return best
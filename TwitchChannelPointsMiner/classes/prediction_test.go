@@ -0,0 +1,101 @@
+package classes
+
+import (
+	"strings"
+	"testing"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestNewPredictionEventMalformed(t *testing.T) {
+	outcome := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "title": "Yes", "total_points": 100.0, "total_users": 2.0}
+	}
+	tests := []struct {
+		name    string
+		event   map[string]interface{}
+		wantErr string
+	}{
+		{"missing event id", map[string]interface{}{"outcomes": []interface{}{outcome("a"), outcome("b")}}, "missing event id"},
+		{"missing outcomes", map[string]interface{}{"id": "e1"}, "no valid outcomes (0 in payload)"},
+		{"non-map outcome", map[string]interface{}{"id": "e1", "outcomes": []interface{}{"truncated"}}, "no valid outcomes (1 in payload)"},
+		{"outcomes without id", map[string]interface{}{"id": "e1", "outcomes": []interface{}{outcome(""), outcome("")}}, "no valid outcomes (2 in payload)"},
+		{"valid", map[string]interface{}{"id": "e1", "outcomes": []interface{}{outcome("a"), outcome("b")}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := NewPredictionEvent(&entities.Streamer{Username: "x"}, tt.event)
+			if tt.wantErr == "" {
+				if err != nil || event == nil {
+					t.Fatalf("NewPredictionEvent() = %v, %v; want an event", event, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewPredictionEvent() error = %v, want %q", err, tt.wantErr)
+			}
+			if event != nil {
+				t.Fatalf("NewPredictionEvent() returned an event along with the error")
+			}
+		})
+	}
+}
+
+func TestMarketFormed(t *testing.T) {
+	outcome := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "total_points": 100.0, "total_users": 2.0}
+	}
+	tests := []struct {
+		name     string
+		strategy entities.Strategy
+		outcomes []interface{}
+		want     bool
+	}{
+		{"one of two populated", entities.StrategyMostVoted, []interface{}{outcome("a"), outcome("")}, false},
+		{"both populated", entities.StrategyMostVoted, []interface{}{outcome("a"), outcome("b")}, true},
+		{"single-outcome payload", entities.StrategyMostVoted, []interface{}{outcome("a")}, true},
+		{"NUMBER_1 on the populated outcome", entities.StrategyNumber1, []interface{}{outcome("a"), outcome("")}, true},
+		{"NUMBER_2 on a missing outcome", entities.StrategyNumber2, []interface{}{outcome("a"), outcome("")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := &entities.Streamer{Username: "x", Settings: entities.StreamerSettings{Bet: entities.BetSettings{Strategy: tt.strategy}}}
+			event, err := NewPredictionEvent(streamer, map[string]interface{}{"id": "e1", "outcomes": tt.outcomes})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, reason := event.MarketFormed()
+			if got != tt.want {
+				t.Fatalf("MarketFormed() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if !got && reason != "only 1 of 2 outcomes populated" {
+				t.Errorf("reason = %q", reason)
+			}
+		})
+	}
+}
+
+func TestSmartTiedOdds(t *testing.T) {
+	outcome := func(users int, percent, odds float64) PredictionOutcome {
+		return PredictionOutcome{TotalUsers: users, PercentageUsers: percent, Odds: odds}
+	}
+	tests := []struct {
+		name     string
+		prefer   entities.TiePrefer
+		outcomes []PredictionOutcome
+		want     int
+	}{
+		{"FIRST keeps the first tied outcome", entities.TiePreferFirst, []PredictionOutcome{outcome(40, 40, 2.5), outcome(45, 45, 2.5), outcome(15, 15, 1.5)}, 0},
+		{"USERS picks the tied outcome with more users", entities.TiePreferUsers, []PredictionOutcome{outcome(40, 40, 2.5), outcome(45, 45, 2.5), outcome(15, 15, 1.5)}, 1},
+		{"USERS keeps the first on equal users", entities.TiePreferUsers, []PredictionOutcome{outcome(50, 50, 2), outcome(50, 50, 2)}, 0},
+		{"USERS ignores outcomes off the best odds", entities.TiePreferUsers, []PredictionOutcome{outcome(40, 40, 3), outcome(45, 45, 2), outcome(15, 15, 1.5)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := entities.BetSettings{Strategy: entities.StrategySmart, SmartTiePrefer: tt.prefer}
+			if got, rationale := selectOutcome(tt.outcomes, settings); got != tt.want {
+				t.Errorf("selectOutcome() = %d (%s), want %d", got, rationale, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,15 +25,73 @@ type Logger interface {
 	DebugEnabled() bool
 }
 
+type PubSubSettings struct {
+	ReconnectDelay        time.Duration
+	RaidJoinCooldown      time.Duration
+	MaxPendingPredictions int
+	// ? presence changes within ReconnectPresenceGrace of a reconnect are double-checked over GQL
+	ReconnectPresenceGrace time.Duration
+	// ? BetLogPath is the JSONL file every placed bet and its result are appended to; empty disables it.
+	BetLogPath string
+	// ? EarnedDedupWindow is how long a points-earned award is remembered to drop redelivered copies; 0 disables it.
+	EarnedDedupWindow time.Duration
+	// ? ConfirmBetViaGQL looks each bet up over GQL to catch stakes Twitch clamped.
+	ConfirmBetViaGQL bool
+	// ? RecoverPanics turns a panic while handling a message, running a connection or firing a prediction timer into
+	// ? a logged error, so the message or timer is dropped or the connection reconnects instead of the process crashing.
+	RecoverPanics bool
+	// ? each PING is sent after a random pause between PingIntervalMin and PingIntervalMax
+	PingIntervalMin time.Duration
+	PingIntervalMax time.Duration
+	// ? PongTimeout forces a reconnect when no PONG arrived for this long; 0 leaves reconnects to read errors.
+	PongTimeout time.Duration
+}
+
+func (s *PubSubSettings) Default() {
+	if s.ReconnectDelay <= 0 {
+		s.ReconnectDelay = 10 * time.Second
+	}
+	if s.MaxPendingPredictions <= 0 {
+		s.MaxPendingPredictions = 50
+	}
+	if s.PingIntervalMin <= 0 {
+		s.PingIntervalMin = 25 * time.Second
+	}
+	if s.PingIntervalMax <= 0 {
+		s.PingIntervalMax = 30 * time.Second
+	}
+	if s.PingIntervalMax < s.PingIntervalMin {
+		s.PingIntervalMax = s.PingIntervalMin
+	}
+}
+
+// ? recentWatchWindow is how fresh the last minute-watched success must be for bet_only_if_watching.
+const recentWatchWindow = 5 * time.Minute
+
+// ? adBreakPresenceGrace is how long after an ad break or squad update a stream-down must be confirmed before it counts.
+const adBreakPresenceGrace = time.Minute
+
 type PubSubClient struct {
 	twitch      *Twitch
 	logger      Logger
+	settings    PubSubSettings
 	streamers   []*entities.Streamer
 	streamerMap map[string]*entities.Streamer
 	predictions map[string]*PredictionEvent
+	tentative   map[string]*PredictionEvent
+	evicted     map[string]*PredictionEvent
 	predMu      sync.Mutex
+	raidMu      sync.Mutex
+	lastRaid    time.Time
+	presenceMu  sync.Mutex
+	graceUntil  map[string]time.Time
+	confirming  map[string]struct{}
+	betLog      *betLog
+	earnedMu    sync.Mutex
+	earnedSeen  map[string]time.Time
 	onGain      func(streamer *entities.Streamer, earned int, reason string, balance int)
-	onPresence  func(streamer *entities.Streamer, online bool, reason string)
+	onPresence  func(streamer *entities.Streamer, online bool, reason string, at time.Time)
+	onNotify    func(Notification)
 }
 
 func (p *PubSubClient) debugf(format string, args ...interface{}) {
@@ -44,8 +105,11 @@ func NewPubSubClient(
 	logger Logger,
 	streamers []*entities.Streamer,
 	onGain func(*entities.Streamer, int, string, int),
-	onPresence func(*entities.Streamer, bool, string),
+	onPresence func(*entities.Streamer, bool, string, time.Time),
+	onNotify func(Notification),
+	settings PubSubSettings,
 ) *PubSubClient {
+	settings.Default()
 	streamerMap := make(map[string]*entities.Streamer)
 	for _, s := range streamers {
 		if s.ChannelID != "" {
@@ -55,11 +119,19 @@ func NewPubSubClient(
 	return &PubSubClient{
 		twitch:      twitch,
 		logger:      logger,
+		settings:    settings,
 		streamers:   streamers,
 		streamerMap: streamerMap,
 		predictions: make(map[string]*PredictionEvent),
+		tentative:   make(map[string]*PredictionEvent),
+		evicted:     make(map[string]*PredictionEvent),
+		graceUntil:  make(map[string]time.Time),
+		confirming:  make(map[string]struct{}),
+		betLog:      newBetLog(settings.BetLogPath),
+		earnedSeen:  make(map[string]time.Time),
 		onGain:      onGain,
 		onPresence:  onPresence,
+		onNotify:    onNotify,
 	}
 }
 
@@ -77,6 +149,7 @@ func (p *PubSubClient) Start(stop <-chan struct{}) {
 }
 
 func (p *PubSubClient) run(connIndex int, topics []string, stop <-chan struct{}) {
+	reconnect := false
 	for {
 		select {
 		case <-stop:
@@ -84,14 +157,101 @@ func (p *PubSubClient) run(connIndex int, topics []string, stop <-chan struct{})
 		default:
 		}
 
-		if err := p.connectAndListen(connIndex, topics, stop); err != nil {
+		err := p.recovered(func() error { return p.connectAndListen(connIndex, topics, stop, reconnect) })
+		if err != nil {
 			p.logger.Errorf("PubSub[%d] connection error: %v", connIndex, err)
-			time.Sleep(10 * time.Second)
+			time.Sleep(p.settings.ReconnectDelay)
+		}
+		reconnect = true
+	}
+}
+
+func (p *PubSubClient) recovered(fn func() error) (err error) {
+	if p.settings.RecoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+	}
+	return fn()
+}
+
+// ? afterFunc is time.AfterFunc with fn run under recovered; a timer goroutine is outside every supervised loop.
+func (p *PubSubClient) afterFunc(name string, d time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(d, func() {
+		if err := p.recovered(func() error { fn(); return nil }); err != nil {
+			p.logger.Errorf("%s: %v", name, err)
+		}
+	})
+}
+
+// ? startPresenceGrace marks the playback topics of a reconnected connection as untrusted for ReconnectPresenceGrace.
+func (p *PubSubClient) startPresenceGrace(topics []string) {
+	if p.settings.ReconnectPresenceGrace <= 0 {
+		return
+	}
+	until := time.Now().Add(p.settings.ReconnectPresenceGrace)
+	p.presenceMu.Lock()
+	defer p.presenceMu.Unlock()
+	for _, topic := range topics {
+		if channelID := strings.TrimPrefix(topic, "video-playback-by-id."); channelID != topic {
+			p.graceUntil[channelID] = until
+		}
+	}
+}
+
+type PendingBet struct {
+	Streamer string
+	Title    string
+	ClosesIn time.Duration
+}
+
+// ? PendingBets lists active predictions that have a bet scheduled but not yet placed.
+func (p *PubSubClient) PendingBets() []PendingBet {
+	now := time.Now()
+	p.predMu.Lock()
+	defer p.predMu.Unlock()
+	pending := make([]PendingBet, 0, len(p.predictions))
+	for _, event := range p.predictions {
+		if event.BetPlaced || event.Status != "ACTIVE" || event.timer == nil || event.Streamer == nil {
+			continue
 		}
+		pending = append(pending, PendingBet{
+			Streamer: event.Streamer.Username,
+			Title:    event.Title,
+			ClosesIn: event.ClosingAfter(now),
+		})
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ClosesIn < pending[j].ClosesIn })
+	return pending
+}
+
+// ? extendPresenceGrace makes presence changes for channelID need confirmation for at least d from now.
+func (p *PubSubClient) extendPresenceGrace(channelID string, d time.Duration) {
+	until := time.Now().Add(d)
+	p.presenceMu.Lock()
+	defer p.presenceMu.Unlock()
+	if until.After(p.graceUntil[channelID]) {
+		p.graceUntil[channelID] = until
+	}
+}
+
+func (p *PubSubClient) inPresenceGrace(channelID string) bool {
+	p.presenceMu.Lock()
+	defer p.presenceMu.Unlock()
+	until, ok := p.graceUntil[channelID]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(p.graceUntil, channelID)
+		return false
 	}
+	return true
 }
 
-func (p *PubSubClient) connectAndListen(connIndex int, topics []string, stop <-chan struct{}) error {
+func (p *PubSubClient) connectAndListen(connIndex int, topics []string, stop <-chan struct{}, reconnect bool) error {
 	dialer := websocket.DefaultDialer
 	conn, _, err := dialer.Dial(constants.WebsocketURL, nil)
 	if err != nil {
@@ -104,6 +264,9 @@ func (p *PubSubClient) connectAndListen(connIndex int, topics []string, stop <-c
 	}
 
 	p.logger.Printf("Connected to Twitch PubSub (conn #%d) with %d topic(s)", connIndex, len(topics))
+	if reconnect {
+		p.startPresenceGrace(topics)
+	}
 
 	lastPong := time.Now()
 	pingTimer := time.NewTimer(p.randomPingInterval())
@@ -118,7 +281,7 @@ func (p *PubSubClient) connectAndListen(connIndex int, topics []string, stop <-c
 				return
 			}
 			p.debugf("PubSub[%d] recv: %s", connIndex, strings.TrimSpace(string(message)))
-			if err := p.handleMessage(message, func() { lastPong = time.Now() }); err != nil {
+			if err := p.recovered(func() error { return p.handleMessage(message, func() { lastPong = time.Now() }) }); err != nil {
 				p.logger.Errorf("PubSub message error: %v", err)
 			}
 		}
@@ -132,8 +295,8 @@ func (p *PubSubClient) connectAndListen(connIndex int, topics []string, stop <-c
 			if err := conn.WriteJSON(map[string]string{"type": "PING"}); err != nil {
 				return err
 			}
-			if time.Since(lastPong) > 5*time.Minute {
-				return fmt.Errorf("last PONG >5m ago, reconnecting")
+			if timeout := p.settings.PongTimeout; timeout > 0 && time.Since(lastPong) > timeout {
+				return fmt.Errorf("last PONG >%s ago, reconnecting", timeout)
 			}
 			pingTimer.Reset(p.randomPingInterval())
 		case err := <-readErr:
@@ -191,6 +354,9 @@ func (p *PubSubClient) buildTopics() ([]string, error) {
 		if s.Settings.CommunityGoals {
 			addTopic(fmt.Sprintf("community-points-channel-v1.%s", s.ChannelID))
 		}
+		if s.Settings.ClaimDrops {
+			addTopic(fmt.Sprintf("broadcast-settings-update.%s", s.ChannelID))
+		}
 	}
 
 	return topics, nil
@@ -273,6 +439,8 @@ func (p *PubSubClient) handleTopicMessage(envelope map[string]interface{}) error
 		return p.processPredictionUser(payload)
 	case strings.HasPrefix(topic, "community-points-channel-v1."):
 		return p.processCommunityPointChannel(topic, payload)
+	case strings.HasPrefix(topic, "broadcast-settings-update."):
+		return p.processBroadcastSettings(topic, payload)
 	default:
 		return nil
 	}
@@ -294,15 +462,140 @@ func (p *PubSubClient) processPlaybackMessage(topic string, payload map[string]i
 		return nil
 	}
 	msgType := strings.ToLower(fmt.Sprint(payload["type"]))
-	switch msgType {
-	case "stream-up", "viewcount":
-		p.onPresence(streamer, true, msgType)
-	case "stream-down":
-		p.onPresence(streamer, false, msgType)
+	var online bool
+	switch {
+	case msgType == "stream-up":
+		online = true
+	case msgType == "viewcount":
+		if viewers, ok := payload["viewers"].(float64); ok && streamer.Stream != nil {
+			streamer.Stream.ViewersCount = int(viewers)
+		}
+		online = true
+	case msgType == "stream-down":
+		online = false
+	case msgType == "commercial":
+		// ? players briefly report the stream as gone while an ad runs; treat stream-down during it as suspect
+		length := time.Duration(fromFloat(payload["length"])) * time.Second
+		p.extendPresenceGrace(channelID, length+adBreakPresenceGrace)
+		p.debugf("Ad break on %s (%s), keeping it online", streamer.Username, length)
+		online = true
+	case strings.HasPrefix(msgType, "squad"):
+		p.extendPresenceGrace(channelID, adBreakPresenceGrace)
+		p.debugf("Squad stream update (%s) on %s, keeping it online", msgType, streamer.Username)
+		online = true
+	default:
+		return nil
+	}
+	at := serverTime(payload, time.Now())
+	if streamer.PresenceKnown && online != streamer.IsOnline && p.inPresenceGrace(channelID) {
+		// ? confirmed off the read loop: a slow GQL call must not hold up the other topics or PONGs on this connection
+		if p.startPresenceCheck(channelID) {
+			go p.confirmPresence(streamer, online, msgType, at)
+		} else {
+			p.debugf("Ignore %s for %s: a confirmation is already running", msgType, streamer.Username)
+		}
+		return nil
 	}
+	p.onPresence(streamer, online, msgType, at)
 	return nil
 }
 
+// ? startPresenceCheck claims the single in-flight presence confirmation of channelID.
+func (p *PubSubClient) startPresenceCheck(channelID string) bool {
+	p.presenceMu.Lock()
+	defer p.presenceMu.Unlock()
+	if _, ok := p.confirming[channelID]; ok {
+		return false
+	}
+	p.confirming[channelID] = struct{}{}
+	return true
+}
+
+// ? confirmPresence checks a presence change reported during the reconnect grace over GQL and applies it if it holds.
+func (p *PubSubClient) confirmPresence(streamer *entities.Streamer, online bool, msgType string, at time.Time) {
+	defer func() {
+		p.presenceMu.Lock()
+		delete(p.confirming, streamer.ChannelID)
+		p.presenceMu.Unlock()
+	}()
+	err := p.recovered(func() error {
+		live, err := p.twitch.StreamerLive(streamer)
+		if err != nil {
+			p.debugf("Ignore %s for %s after reconnect: confirmation failed: %v", msgType, streamer.Username, err)
+			return nil
+		}
+		if live != online {
+			p.debugf("Ignore stale %s for %s after reconnect", msgType, streamer.Username)
+			return nil
+		}
+		p.onPresence(streamer, online, msgType+",confirmed", at)
+		return nil
+	})
+	if err != nil {
+		p.logger.Errorf("presence confirmation for %s: %v", streamer.Username, err)
+	}
+}
+
+// ? maxServerTimeSkew bounds how old server_time may be before it is distrusted as a replay or a skewed clock.
+const maxServerTimeSkew = 10 * time.Minute
+
+// ? serverTime returns the payload's server_time (float epoch seconds), or now when it is missing or implausible.
+func serverTime(payload map[string]interface{}, now time.Time) time.Time {
+	seconds, ok := payload["server_time"].(float64)
+	if !ok || seconds <= 0 {
+		return now
+	}
+	at := time.Unix(0, int64(seconds*float64(time.Second)))
+	// ? never date an event into the future; a few seconds of clock drift would otherwise shift the gap checks
+	if at.After(now) || now.Sub(at) > maxServerTimeSkew {
+		return now
+	}
+	return at
+}
+
+// ? processBroadcastSettings refreshes drop eligibility as soon as a streamer switches game mid-stream.
+func (p *PubSubClient) processBroadcastSettings(topic string, payload map[string]interface{}) error {
+	channelID := strings.TrimPrefix(topic, "broadcast-settings-update.")
+	streamer := p.streamerMap[channelID]
+	if streamer == nil || !streamer.Settings.ClaimDrops {
+		return nil
+	}
+	oldGameID := idString(payload["old_game_id"])
+	gameID := idString(payload["game_id"])
+	if gameID == oldGameID {
+		return nil
+	}
+	game := stringOrDefault(payload["game"])
+	recorded := streamer.SetStreamGame(map[string]interface{}{
+		"id":          gameID,
+		"name":        game,
+		"displayName": game,
+	})
+	if recorded {
+		// ? the campaign lookup is a GQL round trip; keep it off the read loop
+		go p.refreshCampaigns(streamer, gameID, game)
+	}
+	return nil
+}
+
+// ? refreshCampaigns loads the drop campaigns after a game switch and stores them unless the game changed again.
+func (p *PubSubClient) refreshCampaigns(streamer *entities.Streamer, gameID, game string) {
+	err := p.recovered(func() error {
+		campaigns, err := p.twitch.CampaignIDsForStreamer(streamer)
+		if err != nil {
+			p.debugf("Campaign refresh for %s after game change failed: %v", streamer.Username, err)
+			return nil
+		}
+		if streamer.SetCampaignIDsForGame(gameID, campaigns) {
+			p.debugf("%s switched game to %q, %d drop campaign(s) available", streamer.Username, game, len(campaigns))
+		}
+		return nil
+	})
+	if err != nil {
+		p.logger.Errorf("campaign refresh for %s: %v", streamer.Username, err)
+	}
+}
+
 func (p *PubSubClient) processRaidMessage(topic string, payload map[string]interface{}) error {
 	channelID := strings.TrimPrefix(topic, "raid.")
 	streamer := p.streamerMap[channelID]
@@ -319,17 +612,71 @@ func (p *PubSubClient) processRaidMessage(topic string, payload map[string]inter
 		return nil
 	}
 	streamer.LastRaidID = raidID
+	previous, ok := p.reserveRaidJoin(streamer, target)
+	if !ok {
+		return nil
+	}
 	if err := p.twitch.JoinRaid(streamer, raidID); err != nil {
+		p.releaseRaidJoin(previous)
 		p.logger.Errorf("join raid %s->%s: %v", streamer.Username, target, err)
 		return nil
 	}
+	streamer.RaidsJoined++
 	if target == "" {
 		target = "raid target"
 	}
-	p.logger.EmojiPrintf(":performing_arts:", "Joining raid from %s to %s", streamer.Username, target)
+	// ? Raid bonuses are paid on the target channel, so they only show up in history when we mine it too.
+	if tracked := p.streamerByLogin(target); tracked != nil {
+		p.logger.EmojiPrintf(":performing_arts:", "Joined raid from %s to %s, any raid bonus is tracked on %s", streamer.Username, target, tracked.Username)
+	} else {
+		p.logger.EmojiPrintf(":performing_arts:", "Joined raid from %s to %s, a raid bonus may be awarded there but %s is not mined", streamer.Username, target, target)
+	}
+	return nil
+}
+
+func (p *PubSubClient) streamerByLogin(login string) *entities.Streamer {
+	for _, s := range p.streamerMap {
+		if strings.EqualFold(s.Username, login) {
+			return s
+		}
+	}
 	return nil
 }
 
+// ? reserveRaidJoin enforces the account-wide cooldown between raid joins so raid trains can't drag us across channels.
+// ? The cooldown starts when the join is reserved, so a raid arriving on another connection meanwhile is skipped;
+// ? it returns the previous join time for releaseRaidJoin in case the join fails.
+func (p *PubSubClient) reserveRaidJoin(streamer *entities.Streamer, target string) (time.Time, bool) {
+	cooldown := p.settings.RaidJoinCooldown
+	if cooldown <= 0 {
+		return time.Time{}, true
+	}
+	p.raidMu.Lock()
+	defer p.raidMu.Unlock()
+	previous := p.lastRaid
+	if !previous.IsZero() {
+		if remaining := cooldown - time.Since(previous); remaining > 0 {
+			if target == "" {
+				target = "raid target"
+			}
+			p.logger.Printf("Skip raid from %s to %s: raid join cooldown (%s left)", streamer.Username, target, remaining.Truncate(time.Second))
+			return previous, false
+		}
+	}
+	p.lastRaid = time.Now()
+	return previous, true
+}
+
+// ? releaseRaidJoin gives back a reservation whose join failed, so the cooldown runs from the last join that worked.
+func (p *PubSubClient) releaseRaidJoin(previous time.Time) {
+	if p.settings.RaidJoinCooldown <= 0 {
+		return
+	}
+	p.raidMu.Lock()
+	defer p.raidMu.Unlock()
+	p.lastRaid = previous
+}
+
 func (p *PubSubClient) processMomentMessage(topic string, payload map[string]interface{}) error {
 	channelID := strings.TrimPrefix(topic, "community-moments-channel-v1.")
 	streamer := p.streamerMap[channelID]
@@ -341,13 +688,19 @@ func (p *PubSubClient) processMomentMessage(topic string, payload map[string]int
 	}
 	data, _ := payload["data"].(map[string]interface{})
 	momentID, _ := data["moment_id"].(string)
-	if momentID == "" {
+	if momentID == "" || p.twitch.AuthHalted() {
 		return nil
 	}
-	if err := p.twitch.ClaimMoment(streamer, momentID); err != nil {
+	// ? a reconnect can replay the activation of a moment that was already claimed
+	claimed, err := p.twitch.ClaimMomentOnce(streamer, momentID)
+	if err != nil {
 		p.logger.Errorf("claim moment %s: %v", streamer.Username, err)
 		return nil
 	}
+	if !claimed {
+		p.debugf("Moment %s on %s already claimed", momentID, streamer.Username)
+		return nil
+	}
 	p.logger.EmojiPrintf(":video_camera:", "%s Claimed Moment", streamer.Username)
 	return nil
 }
@@ -370,18 +723,51 @@ func (p *PubSubClient) processPointsEarned(payload map[string]interface{}) error
 	if pointGain == nil {
 		return nil
 	}
-	reason := strings.ToUpper(fmt.Sprint(pointGain["reason_code"]))
+	rawReason := stringOrDefault(pointGain["reason_code"])
+	reason, known := entities.NormalizeReason(rawReason)
+	if !known {
+		p.debugf("Unrecognized points reason_code %q for %s, recorded as %s", rawReason, streamer.Username, reason)
+	}
 	earned := int(fromFloat(pointGain["total_points"]))
-	balance := streamer.ChannelPoints
-	if balanceValue := navigate(data, "balance.balance"); balanceValue != nil {
+	balance := 0
+	balanceValue := navigate(data, "balance.balance")
+	if balanceValue != nil {
 		balance = int(fromFloat(balanceValue))
 	}
+	// ? two real awards of the same size still differ in resulting balance and timestamp, so only redeliveries share a key;
+	// ? without either there is nothing to tell them apart and every message counts
+	timestamp := data["timestamp"]
+	key := fmt.Sprintf("%s|%s|%d|%v|%v", channelID, reason, earned, balanceValue, timestamp)
+	if (balanceValue != nil || timestamp != nil) && p.seenEarned(key, time.Now()) {
+		p.debugf("Ignore duplicate points-earned for %s: +%d %s", streamer.Username, earned, reason)
+		return nil
+	}
 	if p.onGain != nil {
 		p.onGain(streamer, earned, reason, balance)
 	}
 	return nil
 }
 
+// ? seenEarned records key and reports whether it was already seen within EarnedDedupWindow.
+func (p *PubSubClient) seenEarned(key string, now time.Time) bool {
+	window := p.settings.EarnedDedupWindow
+	if window <= 0 {
+		return false
+	}
+	p.earnedMu.Lock()
+	defer p.earnedMu.Unlock()
+	for k, at := range p.earnedSeen {
+		if now.Sub(at) > window {
+			delete(p.earnedSeen, k)
+		}
+	}
+	if _, ok := p.earnedSeen[key]; ok {
+		return true
+	}
+	p.earnedSeen[key] = now
+	return false
+}
+
 func (p *PubSubClient) processClaimAvailable(payload map[string]interface{}) error {
 	data, _ := payload["data"].(map[string]interface{})
 	if data == nil {
@@ -394,10 +780,14 @@ func (p *PubSubClient) processClaimAvailable(payload map[string]interface{}) err
 		channelID = fmt.Sprint(data["channel_id"])
 	}
 	streamer := p.streamerMap[channelID]
-	if streamer == nil || claimID == "" {
+	if streamer == nil || claimID == "" || p.twitch.AuthHalted() {
 		return nil
 	}
-	if err := p.twitch.ClaimBonus(streamer, claimID); err != nil {
+	if !streamer.Settings.ClaimBonus {
+		p.debugf("Skip bonus claim for %s: claim_bonus is off", streamer.Username)
+		return nil
+	}
+	if _, err := p.twitch.ClaimBonus(streamer, claimID); err != nil {
 		p.logger.Errorf("claim bonus %s: %v", streamer.Username, err)
 	}
 	return nil
@@ -424,22 +814,45 @@ func (p *PubSubClient) processPredictionChannel(topic string, payload map[string
 		if status != "ACTIVE" || eventID == "" {
 			return nil
 		}
-		window := eventMap["prediction_window_seconds"]
-		eventMap["prediction_window_seconds"] = streamer.PredictionWindowSeconds(fromFloat(window))
-		event := NewPredictionEvent(streamer, eventMap)
-		if event == nil {
+		window := fromFloat(eventMap["prediction_window_seconds"])
+		if streamer.Settings.Bet.SkipsDelay(window) {
+			// ? the delay plus latency would miss a window this short; bet on whatever the pool holds now
+			p.debugf("Prediction window of %ss on %s is below min_window_for_delay_seconds, betting immediately", formatFloat(window), streamer.Username)
+			eventMap["prediction_window_seconds"] = 0.0
+		} else {
+			eventMap["prediction_window_seconds"] = streamer.PredictionWindowSeconds(window)
+		}
+		event, err := NewPredictionEvent(streamer, eventMap)
+		if err != nil {
+			p.debugf("Drop malformed prediction %q for %s: %v", eventID, streamer.Username, err)
 			return nil
 		}
-		if streamer.Settings.Bet.MinimumPoints != nil && streamer.ChannelPoints <= *streamer.Settings.Bet.MinimumPoints {
+		if skip := streamer.Settings.Bet.SkipUnaffordable; skip != nil && *skip {
+			// ? no timer for a bet that cannot be placed; placePrediction checks again with the balance at close
+			if reason := unaffordableReason(streamer); reason != "" {
+				p.logger.Printf("Skip prediction for %s: %s", streamer.Username, reason)
+				return nil
+			}
+		} else if streamer.Settings.Bet.MinimumPoints != nil && streamer.ChannelPoints <= *streamer.Settings.Bet.MinimumPoints {
+			return nil
+		}
+		if !event.HasEnoughOutcomes() {
+			p.logger.Printf("Skip prediction for %s: only %d outcome(s)", streamer.Username, len(event.Outcomes))
 			return nil
 		}
 		wait := event.ClosingAfter(time.Now())
 		p.predMu.Lock()
 		p.predictions[event.EventID] = event
-		p.predMu.Unlock()
-		time.AfterFunc(wait, func() {
+		p.evictPredictionsLocked()
+		if _, ok := p.predictions[event.EventID]; !ok {
+			// ? the new event was the oldest one and is already evicted
+			p.predMu.Unlock()
+			return nil
+		}
+		event.timer = p.afterFunc("prediction timer", wait, func() {
 			p.placePrediction(event.EventID)
 		})
+		p.predMu.Unlock()
 		p.logger.EmojiPrintf(":alarm_clock:", "Place bet after %s for %s", wait.Truncate(time.Second), streamer.Username)
 	case "event-updated":
 		var existing *PredictionEvent
@@ -449,6 +862,9 @@ func (p *PubSubClient) processPredictionChannel(topic string, payload map[string
 			existing.Status = status
 			if outcomes, ok := eventMap["outcomes"].([]interface{}); ok {
 				existing.UpdateOutcomes(outcomes)
+				if existing.BetPlaced {
+					existing.PoolIncludesBet = true
+				}
 			}
 		}
 		p.predMu.Unlock()
@@ -459,6 +875,71 @@ func (p *PubSubClient) processPredictionChannel(topic string, payload map[string
 	return nil
 }
 
+// ? evictPredictionsLocked drops the oldest pending events once MaxPendingPredictions is exceeded, preferring events
+// ? we haven't bet on. An evicted event with a bet and no result yet moves to p.evicted: the user topic can still
+// ? settle it, and lookupEvictedResult asks GQL for the result meanwhile. Caller must hold p.predMu.
+func (p *PubSubClient) evictPredictionsLocked() {
+	limit := p.settings.MaxPendingPredictions
+	for len(p.predictions) > limit {
+		var oldest, oldestUnbet *PredictionEvent
+		for _, ev := range p.predictions {
+			if oldest == nil || ev.CreatedAt.Before(oldest.CreatedAt) {
+				oldest = ev
+			}
+			if !ev.BetPlaced && (oldestUnbet == nil || ev.CreatedAt.Before(oldestUnbet.CreatedAt)) {
+				oldestUnbet = ev
+			}
+		}
+		if oldestUnbet != nil {
+			oldest = oldestUnbet
+		}
+		if oldest.timer != nil {
+			oldest.timer.Stop()
+		}
+		delete(p.predictions, oldest.EventID)
+		p.logger.Printf("Evicted pending %s: more than %d predictions pending", oldest.String(), limit)
+		if oldest.BetPlaced && oldest.ResultType == "" {
+			event := oldest
+			p.evicted[event.EventID] = event
+			p.afterFunc("evicted prediction lookup", evictedResultInterval, func() { p.lookupEvictedResult(event, 1) })
+		}
+	}
+}
+
+// ? evictedResultInterval spaces the GQL result lookups of an evicted bet; evictedResultAttempts bounds them.
+const (
+	evictedResultInterval = 2 * time.Minute
+	evictedResultAttempts = 15
+)
+
+// ? lookupEvictedResult logs an evicted bet's result from GQL unless the user topic delivered it first.
+func (p *PubSubClient) lookupEvictedResult(event *PredictionEvent, attempt int) {
+	p.predMu.Lock()
+	_, pending := p.evicted[event.EventID]
+	p.predMu.Unlock()
+	if !pending {
+		return
+	}
+	result, err := p.twitch.PredictionResult(event)
+	if result == nil && err == nil && attempt < evictedResultAttempts {
+		p.afterFunc("evicted prediction lookup", evictedResultInterval, func() { p.lookupEvictedResult(event, attempt+1) })
+		return
+	}
+	p.predMu.Lock()
+	_, pending = p.evicted[event.EventID]
+	delete(p.evicted, event.EventID)
+	p.predMu.Unlock()
+	if !pending {
+		// ? the user topic settled it during the lookup
+		return
+	}
+	if result == nil {
+		p.logger.Errorf("No result for evicted %s over GQL: %v", event.String(), err)
+		return
+	}
+	p.logPredictionResult(event, result, false)
+}
+
 func (p *PubSubClient) processPredictionUser(payload map[string]interface{}) error {
 	data, _ := payload["data"].(map[string]interface{})
 	if data == nil {
@@ -471,12 +952,24 @@ func (p *PubSubClient) processPredictionUser(payload map[string]interface{}) err
 	eventID := fmt.Sprint(predictionData["event_id"])
 	p.predMu.Lock()
 	event, ok := p.predictions[eventID]
+	if !ok {
+		event, ok = p.tentative[eventID]
+	}
+	if !ok {
+		event, ok = p.evicted[eventID]
+	}
 	p.predMu.Unlock()
 	if !ok || event == nil {
 		return nil
 	}
+	messageType := strings.ToLower(fmt.Sprint(payload["type"]))
+	// ? event IDs are globally unique, but a mismatched channel means the message is not about this bet; never apply it
+	if channelID := stringOrDefault(predictionData["channel_id"]); channelID != "" && event.Streamer != nil && channelID != event.Streamer.ChannelID {
+		p.logger.Errorf("%s for event %s names channel %s, but the event belongs to %s (%s); ignoring it", messageType, eventID, channelID, event.Streamer.Username, event.Streamer.ChannelID)
+		return nil
+	}
 
-	switch strings.ToLower(fmt.Sprint(payload["type"])) {
+	switch messageType {
 	case "prediction-made":
 		event.BetConfirmed = true
 	case "prediction-result":
@@ -488,14 +981,88 @@ func (p *PubSubClient) processPredictionUser(payload map[string]interface{}) err
 			// ? Assume confirmation if Twitch skipped sending prediction-made
 			event.BetConfirmed = true
 		}
-		p.logPredictionResult(event, result)
+		p.logPredictionResult(event, result, false)
+		p.predMu.Lock()
+		delete(p.predictions, eventID)
+		delete(p.tentative, eventID)
+		delete(p.evicted, eventID)
+		p.predMu.Unlock()
+	case "prediction-refunded", "prediction-refund", "prediction-canceled", "prediction-cancelled":
+		// ? a cancellation may arrive only on the user topic; treat it as a REFUND so the event is logged and released
+		p.logPredictionResult(event, map[string]interface{}{"type": "REFUND"}, false)
 		p.predMu.Lock()
+		if event.timer != nil {
+			event.timer.Stop()
+		}
 		delete(p.predictions, eventID)
+		delete(p.tentative, eventID)
+		delete(p.evicted, eventID)
 		p.predMu.Unlock()
 	}
 	return nil
 }
 
+// ? betConfirmDelay gives Twitch time to record the bet before confirm_bet_via_gql looks it up.
+const betConfirmDelay = 3 * time.Second
+
+// ? confirmBet reads back the stake and outcome Twitch recorded for the bet. When they differ from the decision
+// ? (Twitch clamps the stake to the balance), the decision and the history entry of the bet are corrected in place
+// ? before the result is accounted.
+func (p *PubSubClient) confirmBet(event *PredictionEvent) {
+	streamer := event.Streamer
+	points, outcomeID, err := p.twitch.ConfirmPrediction(event)
+	if err != nil {
+		p.logger.Printf("confirm bet for %s: %v", streamer.Username, err)
+		return
+	}
+	p.predMu.Lock()
+	stake, intended := event.Decision.Amount, event.Decision.OutcomeID
+	settled := event.ResultType != ""
+	if !settled {
+		event.Decision.Amount = points
+		if outcomeID != "" && outcomeID != intended {
+			for i, outcome := range event.Outcomes {
+				if outcome.ID == outcomeID {
+					event.Decision.Choice = i
+					event.Decision.OutcomeID = outcomeID
+				}
+			}
+		}
+	}
+	outcome := event.DecisionOutcomeString()
+	p.predMu.Unlock()
+	switch {
+	case points == stake && (outcomeID == "" || outcomeID == intended):
+		p.logger.Printf("Confirmed %s points on %s for %s", formatNumber(points), outcome, streamer.Username)
+	case settled:
+		p.logger.Errorf("Twitch recorded %d points on outcome %s for the bet on %s, but it is already settled; leaving it", points, outcomeID, streamer.Username)
+	default:
+		p.logger.Errorf("Twitch recorded %d points on %s instead of %d for the bet on %s; correcting the stake", points, outcome, stake, streamer.Username)
+		// ? placePrediction recorded -stake; shift that entry to -points without counting a second bet
+		adjustHistory(streamer, entities.ReasonPrediction, stake-points)
+	}
+}
+
+// ? unaffordableReason explains why streamer cannot stake Twitch's minimum of 10 under minimum_points,
+// ? points_reserve and house_money_only, or returns "" when a bet is possible.
+func unaffordableReason(streamer *entities.Streamer) string {
+	if minimum := streamer.Settings.Bet.MinimumPoints; minimum != nil && streamer.ChannelPoints <= *minimum {
+		return fmt.Sprintf("balance %d <= minimum_points %d", streamer.ChannelPoints, *minimum)
+	}
+	if streamer.ChannelPoints < 10 {
+		return fmt.Sprintf("balance %d below Twitch minimum 10", streamer.ChannelPoints)
+	}
+	if reserve := streamer.Settings.PointsReserve; reserve > 0 && streamer.SpendablePoints() < 10 {
+		return fmt.Sprintf("balance %d within points_reserve %d", streamer.ChannelPoints, reserve)
+	}
+	if streamer.Settings.HouseMoneyOnly {
+		if profit := streamer.SessionProfit(); profit < 10 {
+			return fmt.Sprintf("session profit %d below 10 (house_money_only)", profit)
+		}
+	}
+	return ""
+}
+
 func (p *PubSubClient) placePrediction(eventID string) {
 	p.predMu.Lock()
 	event, ok := p.predictions[eventID]
@@ -504,19 +1071,66 @@ func (p *PubSubClient) placePrediction(eventID string) {
 		return
 	}
 	streamer := event.Streamer
+	if p.twitch.AuthHalted() {
+		p.logger.Printf("Skip bet for %s: auth is failing", streamer.Username)
+		return
+	}
 	if event.Status != "ACTIVE" {
 		p.logger.Printf("Skip bet for %s: event status is %s", streamer.Username, event.Status)
 		return
 	}
-	if streamer.Settings.Bet.MinimumPoints != nil && streamer.ChannelPoints <= *streamer.Settings.Bet.MinimumPoints {
-		p.logger.Printf("Skip bet for %s: balance %d <= minimum_points %d", streamer.Username, streamer.ChannelPoints, *streamer.Settings.Bet.MinimumPoints)
+	if reason := unaffordableReason(streamer); reason != "" {
+		p.logger.Printf("Skip bet for %s: %s", streamer.Username, reason)
 		return
 	}
+	if streamer.Settings.BetOnlyIfWatching && !streamer.WatchedWithin(recentWatchWindow, time.Now()) {
+		p.logger.Printf("Skip bet for %s: not watched in the last %s (bet_only_if_watching)", streamer.Username, recentWatchWindow)
+		return
+	}
+	reserve := streamer.Settings.PointsReserve
+	spendable := streamer.SpendablePoints()
+	houseMoney := streamer.Settings.HouseMoneyOnly
+	profit := streamer.SessionProfit()
+	if !event.HasEnoughOutcomes() {
+		p.logger.Printf("Skip bet for %s: only %d outcome(s)", streamer.Username, len(event.Outcomes))
+		return
+	}
+	if ok, reason := event.MarketFormed(); !ok {
+		p.logger.Printf("Skip bet for %s: %s", streamer.Username, reason)
+		return
+	}
+	broadcastID := ""
+	if streamer.Stream != nil {
+		broadcastID = streamer.Stream.BroadcastID
+	}
+	if limit := streamer.Settings.Bet.MaxBetsPerStream; limit != nil && *limit > 0 && streamer.BetsThisStream(broadcastID) >= *limit {
+		p.logger.Printf("Skip bet for %s: %d bet(s) already placed this stream (max_bets_per_stream)", streamer.Username, *limit)
+		return
+	}
+	// ? decided under predMu so an event-updated arriving now cannot swap the outcomes mid-decision; the pool is
+	// ? whatever the most recent update left, which for LAST_MOMENT is at most a few seconds old
+	p.predMu.Lock()
 	decision := event.Decide(streamer.ChannelPoints)
+	poolAge := time.Since(event.outcomesAt)
+	p.predMu.Unlock()
+	p.debugf("Decision for %s (%s) on pool data from %s ago: %s", streamer.Username, event.Title, poolAge.Truncate(time.Second), decision.Rationale)
 	if decision.OutcomeID == "" {
 		p.logger.Printf("Skip bet for %s: no outcome selected", streamer.Username)
 		return
 	}
+	if decision.EventCappedFrom > 0 {
+		p.logger.Printf("Event limit caps bet for %s: %d -> %d", streamer.Username, decision.EventCappedFrom, event.MaxPerUser)
+	}
+	if reserve > 0 && decision.Amount > spendable {
+		p.logger.Printf("points_reserve %d caps bet for %s: %d -> %d", reserve, streamer.Username, decision.Amount, spendable)
+		decision.Amount = spendable
+		event.Decision.Amount = spendable
+	}
+	if houseMoney && decision.Amount > profit {
+		p.logger.Printf("house_money_only caps bet for %s: %d -> %d", streamer.Username, decision.Amount, profit)
+		decision.Amount = profit
+		event.Decision.Amount = profit
+	}
 	if decision.Amount < 10 {
 		reason := fmt.Sprintf("balance %d below Twitch minimum 10", streamer.ChannelPoints)
 		if streamer.ChannelPoints >= 10 {
@@ -533,7 +1147,11 @@ func (p *PubSubClient) placePrediction(eventID string) {
 		p.logger.Errorf("prediction %s: %v", streamer.Username, err)
 		return
 	}
+	if p.settings.ConfirmBetViaGQL {
+		p.afterFunc("bet confirmation", betConfirmDelay, func() { p.confirmBet(event) })
+	}
 	event.BetPlaced = true
+	streamer.RecordBet(broadcastID)
 	// Ensure we log results even if Twitch doesn't emit prediction-made
 	event.BetConfirmed = true
 	outcome := event.DecisionOutcomeString()
@@ -541,7 +1159,30 @@ func (p *PubSubClient) placePrediction(eventID string) {
 		outcome = decision.OutcomeID
 	}
 	p.logger.EmojiPrintf(":four_leaf_clover:", "Place %s points on: %s for %s", formatNumber(decision.Amount), outcome, streamer.Username)
-	recordHistory(streamer, "PREDICTION", -decision.Amount)
+	entry := betLogEntry{
+		Type:     "bet",
+		EventID:  event.EventID,
+		Streamer: streamer.Username,
+		Title:    event.Title,
+		Outcome:  outcome,
+		Stake:    decision.Amount,
+		Strategy: string(decision.Strategy),
+	}
+	if out := event.DecisionOutcome(); out != nil {
+		entry.Odds = out.Odds
+	}
+	if err := p.betLog.append(entry); err != nil {
+		p.logger.Errorf("bet log: %v", err)
+	}
+	recordHistory(streamer, entities.ReasonPrediction, -decision.Amount)
+	p.notify(Notification{
+		Kind:     NotifyBet,
+		Streamer: streamer.Username,
+		Points:   streamer.ChannelPoints,
+		Title:    event.Title,
+		Outcome:  outcome,
+		Amount:   decision.Amount,
+	})
 }
 
 func (p *PubSubClient) processCommunityPointChannel(topic string, payload map[string]interface{}) error {
@@ -551,15 +1192,12 @@ func (p *PubSubClient) processCommunityPointChannel(topic string, payload map[st
 		return nil
 	}
 	msgType := strings.ToLower(fmt.Sprint(payload["type"]))
-	if streamer.CommunityGoals == nil {
-		streamer.CommunityGoals = make(map[string]*entities.CommunityGoal)
-	}
 	switch msgType {
 	case "community-goal-created", "community-goal-updated":
 		data, _ := payload["data"].(map[string]interface{})
 		goalData, _ := data["community_goal"].(map[string]interface{})
 		if goal := entities.NewCommunityGoalFromPubSub(goalData); goal != nil && goal.ID != "" {
-			streamer.CommunityGoals[goal.ID] = goal
+			streamer.SetCommunityGoal(goal)
 		}
 		p.twitch.ContributeToCommunityGoals(streamer)
 	case "community-goal-deleted":
@@ -567,14 +1205,33 @@ func (p *PubSubClient) processCommunityPointChannel(topic string, payload map[st
 		goalData, _ := data["community_goal"].(map[string]interface{})
 		id := stringOrDefault(goalData["id"])
 		if id != "" {
-			delete(streamer.CommunityGoals, id)
+			streamer.DeleteCommunityGoal(id)
 		}
 	}
 	return nil
 }
 
+func (p *PubSubClient) notify(n Notification) {
+	if p.onNotify != nil {
+		p.onNotify(n)
+	}
+}
+
 func (p *PubSubClient) randomPingInterval() time.Duration {
-	return time.Duration(randomInt(25, 30)) * time.Second
+	return time.Duration(randomInt(int(p.settings.PingIntervalMin/time.Second), int(p.settings.PingIntervalMax/time.Second))) * time.Second
+}
+
+func idString(v interface{}) string {
+	switch id := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	default:
+		return fmt.Sprint(id)
+	}
 }
 
 func chunkTopics(topics []string, chunkSize int) [][]string {
@@ -609,11 +1266,41 @@ func recordHistory(streamer *entities.Streamer, reason string, amount int) {
 	entry.Amount += amount
 }
 
-func (p *PubSubClient) logPredictionResult(event *PredictionEvent, result map[string]interface{}) {
+// ? adjustHistory corrects the amount of an entry already recorded, leaving its count alone.
+func adjustHistory(streamer *entities.Streamer, reason string, amount int) {
+	if streamer == nil || streamer.History == nil {
+		return
+	}
+	if entry, ok := streamer.History[reason]; ok {
+		entry.Amount += amount
+	}
+}
+
+// ? logPredictionResult records a result once. A tentative result (inferred from the channel topic) can be replaced
+// ? by the authoritative user-topic result: its history changes are reversed first, so LOSE then REFUND nets out,
+// ? and a WIN whose estimated payout differs from Twitch's points_won is re-applied with the real payout.
+func (p *PubSubClient) logPredictionResult(event *PredictionEvent, result map[string]interface{}, tentative bool) {
 	if event == nil || result == nil {
 		return
 	}
+	previous := event.ResultType
+	if previous != "" {
+		if tentative || !event.resultTentative {
+			return
+		}
+		event.resultTentative = false
+		gained, _, _ := event.resultGain(result)
+		if strings.EqualFold(stringOrDefault(result["type"]), previous) && gained == event.settledGained {
+			return
+		}
+		previous = event.ResultString
+		p.reverseResultHistory(event)
+	}
+	event.resultTentative = tentative
 	gained, placed, won, resultType, resultString := event.ParseResult(result)
+	if previous != "" {
+		resultString = fmt.Sprintf("%s (corrects %s)", resultString, previous)
+	}
 	event.BetConfirmed = true
 	decisionLabel := event.DecisionLabel()
 	if decisionLabel == "" {
@@ -635,17 +1322,86 @@ func (p *PubSubClient) logPredictionResult(event *PredictionEvent, result map[st
 		constants.ColorReset,
 	)
 	if streamer := event.Streamer; streamer != nil {
+		outcome := ""
+		if out := event.DecisionOutcome(); out != nil {
+			outcome = out.Title
+		}
+		p.notify(Notification{
+			Kind:     NotifyPredictionResult,
+			Streamer: streamer.Username,
+			Points:   streamer.ChannelPoints,
+			Title:    event.Title,
+			Outcome:  outcome,
+			Amount:   placed,
+			Gained:   gained,
+			Result:   resultString,
+		})
+		if event.BetPlaced {
+			err := p.betLog.append(betLogEntry{
+				Type:     "result",
+				EventID:  event.EventID,
+				Streamer: streamer.Username,
+				Title:    event.Title,
+				Outcome:  outcome,
+				Stake:    placed,
+				Result:   resultType,
+				Gained:   gained,
+			})
+			if err != nil {
+				p.logger.Errorf("bet log: %v", err)
+			}
+		}
+		// ? refunds settle with placed == 0, so they count toward neither side of the ROI
+		streamer.TotalWagered += placed
+		streamer.PredictionNet += gained
+		event.settledPlaced, event.settledGained = placed, gained
+		if placed > 0 && (resultType == "WIN" || resultType == "LOSE") {
+			streamer.LastBetResult = resultType
+		}
 		if gained != 0 {
-			recordHistory(streamer, "PREDICTION", gained)
+			p.recordResultHistory(event, entities.ReasonPrediction, gained)
 		}
-		if resultType == "REFUND" && placed > 0 {
-			recordHistory(streamer, "REFUND", -placed)
+		if resultType == "REFUND" && event.Decision.Amount > 0 {
+			// ? the stake comes back, cancelling the PREDICTION entry recorded when the bet was placed
+			p.recordResultHistory(event, entities.ReasonRefund, event.Decision.Amount)
 		} else if resultType == "WIN" && won > 0 {
-			recordHistory(streamer, "PREDICTION", -won)
+			p.recordResultHistory(event, entities.ReasonPrediction, -won)
 		}
 	}
 }
 
+func (p *PubSubClient) recordResultHistory(event *PredictionEvent, reason string, amount int) {
+	recordHistory(event.Streamer, reason, amount)
+	event.resultHistory = append(event.resultHistory, historyDelta{reason: reason, amount: amount})
+}
+
+// ? reverseResultHistory undoes every history change made by the event's previous result.
+func (p *PubSubClient) reverseResultHistory(event *PredictionEvent) {
+	streamer := event.Streamer
+	if streamer != nil {
+		streamer.TotalWagered -= event.settledPlaced
+		streamer.PredictionNet -= event.settledGained
+	}
+	event.settledPlaced, event.settledGained = 0, 0
+	for _, delta := range event.resultHistory {
+		if streamer == nil || streamer.History == nil {
+			break
+		}
+		entry, ok := streamer.History[delta.reason]
+		if !ok {
+			continue
+		}
+		entry.Count--
+		entry.Amount -= delta.amount
+		if entry.Count <= 0 && entry.Amount == 0 {
+			delete(streamer.History, delta.reason)
+		}
+	}
+	event.resultHistory = nil
+	event.ResultType = ""
+	event.ResultString = ""
+}
+
 func (p *PubSubClient) resolvePredictionFromChannel(event *PredictionEvent, eventMap map[string]interface{}) {
 	if event == nil || event.Decision.Amount == 0 || event.ResultType != "" {
 		return
@@ -668,20 +1424,35 @@ func (p *PubSubClient) resolvePredictionFromChannel(event *PredictionEvent, even
 		}
 		if event.Decision.OutcomeID == winningID {
 			resultType = "WIN"
-			pointsWon = payoutForOutcome(event.Decision, event.Outcomes, winningID)
+			pointsWon = payoutForOutcome(event.Decision, event.Outcomes, winningID, event.PoolIncludesBet)
 		}
 	}
 
 	p.logPredictionResult(event, map[string]interface{}{
 		"type":       resultType,
 		"points_won": pointsWon,
-	})
-
+	}, true)
+	if event.ResultType == "" || !event.resultTentative {
+		return
+	}
+	// ? The event leaves the pending set but stays reachable for tentativeResultWindow, so the user-topic result
+	// ? can still correct this inferred one (e.g. a TOS refund).
+	now := time.Now()
 	p.predMu.Lock()
+	defer p.predMu.Unlock()
 	delete(p.predictions, event.EventID)
-	p.predMu.Unlock()
+	event.settledAt = now
+	p.tentative[event.EventID] = event
+	for id, ev := range p.tentative {
+		if now.Sub(ev.settledAt) > tentativeResultWindow {
+			delete(p.tentative, id)
+		}
+	}
 }
 
+// ? tentativeResultWindow is how long an inferred result waits for the user topic to confirm or correct it.
+const tentativeResultWindow = 15 * time.Minute
+
 func winningOutcomeID(event map[string]interface{}) string {
 	if id := stringOrDefault(event["winning_outcome_id"]); id != "" {
 		return id
@@ -708,7 +1479,9 @@ func winningOutcomeID(event map[string]interface{}) string {
 	return ""
 }
 
-func payoutForOutcome(decision PredictionDecision, outcomes []PredictionOutcome, winningID string) int {
+// ? payoutForOutcome mirrors Twitch's parimutuel payout: winners split the whole pool pro rata to their stake, rounded down.
+// ? When the pool snapshot predates our bet, our stake is added to both the winning side and the total first.
+func payoutForOutcome(decision PredictionDecision, outcomes []PredictionOutcome, winningID string, poolIncludesBet bool) int {
 	if decision.Amount <= 0 || decision.OutcomeID != winningID {
 		return 0
 	}
@@ -721,12 +1494,16 @@ func payoutForOutcome(decision PredictionDecision, outcomes []PredictionOutcome,
 			winPoints = oc.TotalPoints
 		}
 	}
+	if !poolIncludesBet || winPoints < decision.Amount {
+		totalPoints += decision.Amount
+		winPoints += decision.Amount
+	}
 
 	if totalPoints == 0 || winPoints == 0 {
 		return decision.Amount
 	}
 
-	payout := int(math.Round(float64(decision.Amount) * (float64(totalPoints) / float64(winPoints))))
+	payout := int(math.Floor(float64(decision.Amount) * float64(totalPoints) / float64(winPoints)))
 	if payout < decision.Amount {
 		return decision.Amount
 	}
@@ -0,0 +1,249 @@
+package classes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestPayoutForOutcome(t *testing.T) {
+	tests := []struct {
+		name            string
+		amount          int
+		pick            string
+		winner          string
+		pool            map[string]int
+		poolIncludesBet bool
+		want            int
+	}{
+		{"pool includes bet", 100, "a", "a", map[string]int{"a": 1000, "b": 3000}, true, 400},
+		{"pool predates bet", 100, "a", "a", map[string]int{"a": 900, "b": 3000}, false, 400},
+		{"floors fractional payout", 100, "a", "a", map[string]int{"a": 300, "b": 700}, true, 333},
+		{"floors after adding stake", 100, "a", "a", map[string]int{"a": 200, "b": 700}, false, 333},
+		{"stale snapshot without our stake", 500, "a", "a", map[string]int{"a": 100, "b": 400}, true, 833},
+		{"only winners bet", 250, "a", "a", map[string]int{"a": 1000}, true, 250},
+		{"lost", 100, "a", "b", map[string]int{"a": 1000, "b": 3000}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcomes := []PredictionOutcome{
+				{ID: "a", TotalPoints: tt.pool["a"]},
+				{ID: "b", TotalPoints: tt.pool["b"]},
+			}
+			decision := PredictionDecision{OutcomeID: tt.pick, Amount: tt.amount}
+			if got := payoutForOutcome(decision, outcomes, tt.winner, tt.poolIncludesBet); got != tt.want {
+				t.Errorf("payoutForOutcome() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+type testLogger struct{}
+
+func (testLogger) Printf(format string, args ...interface{})             {}
+func (testLogger) Errorf(format string, args ...interface{})             {}
+func (testLogger) EmojiPrintf(emoji, format string, args ...interface{}) {}
+func (testLogger) Debugf(format string, args ...interface{})             {}
+func (testLogger) DebugEnabled() bool                                    { return false }
+
+func TestTentativeLoseThenRefund(t *testing.T) {
+	p := &PubSubClient{
+		logger:      testLogger{},
+		predictions: make(map[string]*PredictionEvent),
+		tentative:   make(map[string]*PredictionEvent),
+	}
+	streamer := &entities.Streamer{Username: "streamer", ChannelID: "1"}
+	event := &PredictionEvent{
+		EventID:   "event",
+		Streamer:  streamer,
+		BetPlaced: true,
+		Decision:  PredictionDecision{OutcomeID: "a", Amount: 100},
+		Outcomes:  []PredictionOutcome{{ID: "a", TotalPoints: 1000}, {ID: "b", TotalPoints: 3000}},
+	}
+	recordHistory(streamer, entities.ReasonPrediction, -100)
+	p.predictions[event.EventID] = event
+
+	p.resolvePredictionFromChannel(event, map[string]interface{}{"status": "RESOLVED", "winning_outcome_id": "b"})
+	if event.ResultType != "LOSE" {
+		t.Fatalf("tentative result = %q, want LOSE", event.ResultType)
+	}
+	if _, ok := p.predictions[event.EventID]; ok {
+		t.Fatal("resolved event still pending")
+	}
+	if _, ok := p.tentative[event.EventID]; !ok {
+		t.Fatal("resolved event not kept for the user-topic result")
+	}
+
+	err := p.processPredictionUser(map[string]interface{}{
+		"type": "prediction-result",
+		"data": map[string]interface{}{
+			"prediction": map[string]interface{}{
+				"event_id": event.EventID,
+				"result":   map[string]interface{}{"type": "REFUND"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ResultType != "REFUND" {
+		t.Errorf("result = %q, want REFUND", event.ResultType)
+	}
+	if streamer.TotalWagered != 0 || streamer.PredictionNet != 0 {
+		t.Errorf("wagered %d, net %d; want 0, 0", streamer.TotalWagered, streamer.PredictionNet)
+	}
+	net := 0
+	for _, entry := range streamer.History {
+		net += entry.Amount
+	}
+	if net != 0 {
+		t.Errorf("history nets %d, want 0", net)
+	}
+	if _, ok := p.tentative[event.EventID]; ok {
+		t.Error("refunded event still tracked")
+	}
+}
+
+type errorLogger struct {
+	testLogger
+	errors chan string
+}
+
+func (l errorLogger) Errorf(format string, args ...interface{}) {
+	l.errors <- fmt.Sprintf(format, args...)
+}
+
+func TestRecoveredPanics(t *testing.T) {
+	p := &PubSubClient{settings: PubSubSettings{RecoverPanics: true}}
+	err := p.recovered(func() error { panic("boom") })
+	if err == nil || !strings.HasPrefix(err.Error(), "panic: boom") {
+		t.Fatalf("recovered() = %v, want the panic as an error", err)
+	}
+	if err := p.recovered(func() error { return nil }); err != nil {
+		t.Fatalf("recovered() = %v for a clean handler", err)
+	}
+
+	logger := errorLogger{errors: make(chan string, 1)}
+	p.logger = logger
+	p.afterFunc("prediction timer", 0, func() { panic("boom") })
+	select {
+	case msg := <-logger.errors:
+		if !strings.HasPrefix(msg, "prediction timer: panic: boom") {
+			t.Errorf("logged %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timer panic was not logged")
+	}
+}
+
+func TestTentativeWinCorrectedPayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		pointsWon int
+	}{
+		{"payout matches the estimate", 400},
+		{"payout differs from the estimate", 390},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settle := func(tentative bool) *entities.Streamer {
+				p := &PubSubClient{
+					logger:      testLogger{},
+					predictions: make(map[string]*PredictionEvent),
+					tentative:   make(map[string]*PredictionEvent),
+				}
+				streamer := &entities.Streamer{Username: "streamer", ChannelID: "1"}
+				event := &PredictionEvent{
+					EventID:         "event",
+					Streamer:        streamer,
+					BetPlaced:       true,
+					PoolIncludesBet: true,
+					Decision:        PredictionDecision{OutcomeID: "a", Amount: 100},
+					Outcomes:        []PredictionOutcome{{ID: "a", TotalPoints: 1000}, {ID: "b", TotalPoints: 3000}},
+				}
+				recordHistory(streamer, entities.ReasonPrediction, -100)
+				p.predictions[event.EventID] = event
+				if tentative {
+					p.resolvePredictionFromChannel(event, map[string]interface{}{"status": "RESOLVED", "winning_outcome_id": "a"})
+					if event.settledGained != 300 {
+						t.Fatalf("estimated gain = %d, want 300", event.settledGained)
+					}
+				}
+				p.logPredictionResult(event, map[string]interface{}{"type": "WIN", "points_won": float64(tt.pointsWon)}, false)
+				return streamer
+			}
+			got, want := settle(true), settle(false)
+			if got.PredictionNet != want.PredictionNet || got.TotalWagered != want.TotalWagered {
+				t.Errorf("net %d, wagered %d; want %d, %d", got.PredictionNet, got.TotalWagered, want.PredictionNet, want.TotalWagered)
+			}
+			if got.PredictionNet != tt.pointsWon-100 {
+				t.Errorf("net %d, want %d", got.PredictionNet, tt.pointsWon-100)
+			}
+			for reason, entry := range want.History {
+				if g := got.History[reason]; g == nil || g.Amount != entry.Amount {
+					t.Errorf("history %s = %+v, want %+v", reason, g, entry)
+				}
+			}
+		})
+	}
+}
+
+func TestEvictBetPredictions(t *testing.T) {
+	p := &PubSubClient{
+		logger:      testLogger{},
+		settings:    PubSubSettings{MaxPendingPredictions: 2},
+		predictions: make(map[string]*PredictionEvent),
+		tentative:   make(map[string]*PredictionEvent),
+		evicted:     make(map[string]*PredictionEvent),
+	}
+	streamer := &entities.Streamer{Username: "streamer", ChannelID: "1"}
+	now := time.Now()
+	add := func(id string, age time.Duration, bet bool) *PredictionEvent {
+		event := &PredictionEvent{
+			EventID:   id,
+			Streamer:  streamer,
+			CreatedAt: now.Add(-age),
+			BetPlaced: bet,
+			Decision:  PredictionDecision{OutcomeID: "a", Amount: 100},
+		}
+		p.predictions[id] = event
+		return event
+	}
+	add("bet-old", 3*time.Minute, true)
+	add("bet-new", 2*time.Minute, true)
+	add("unbet", time.Minute, false)
+	p.evictPredictionsLocked()
+	if _, ok := p.predictions["unbet"]; ok || len(p.predictions) != 2 {
+		t.Fatalf("predictions = %v, want the unbet event evicted first", p.predictions)
+	}
+
+	add("bet-newest", 0, true)
+	p.evictPredictionsLocked()
+	if len(p.predictions) != 2 {
+		t.Fatalf("%d predictions pending, want the cap of 2", len(p.predictions))
+	}
+	if _, ok := p.evicted["bet-old"]; !ok {
+		t.Fatal("evicted bet is not kept for its result")
+	}
+
+	recordHistory(streamer, entities.ReasonPrediction, -100)
+	err := p.processPredictionUser(map[string]interface{}{
+		"type": "prediction-result",
+		"data": map[string]interface{}{"prediction": map[string]interface{}{
+			"event_id": "bet-old",
+			"result":   map[string]interface{}{"type": "WIN", "points_won": float64(250)},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.evicted["bet-old"]; ok {
+		t.Error("settled evicted bet is still tracked")
+	}
+	if streamer.PredictionNet != 150 {
+		t.Errorf("net %d, want 150", streamer.PredictionNet)
+	}
+}
@@ -13,7 +13,11 @@ import (
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
@@ -21,14 +25,61 @@ import (
 	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/utils"
 )
 
-var ErrStreamerOffline = errors.New("streamer offline")
+var (
+	ErrStreamerOffline  = errors.New("streamer offline")
+	ErrSpadeUnavailable = errors.New("spade url unavailable")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+// ? WatchProfiles are named sets of minute-watched properties applied before SpadeExtraProps.
+// ? "site" is the regular browser player; "low_bandwidth" reports the lowest-quality, muted popout player.
+var WatchProfiles = map[string]map[string]interface{}{
+	"site": {},
+	"low_bandwidth": {
+		"player":  "popout",
+		"quality": "160p30",
+		"muted":   true,
+	},
+}
 
-type debugLogger interface {
-	Debugf(format string, args ...interface{})
-	DebugEnabled() bool
+type TwitchSettings struct {
+	// ? DropsRewardWhitelist limits drop claims to rewards whose name contains one of these entries (case-insensitive).
+	DropsRewardWhitelist []string
+	MaxConcurrentGQL     int
+	// ? MaxConcurrentWatch bounds minute-watched requests in flight at once, whoever sends them.
+	MaxConcurrentWatch int
+	// ? SpadeExtraProps is merged over the minute-watched properties, so it can also override the built-in ones.
+	SpadeExtraProps map[string]interface{}
+	// ? WatchProfile names an entry of WatchProfiles; unknown names behave like "site".
+	WatchProfile string
+	// ? SpadeMaxAge is how long a spade URL is reused before it is fetched again.
+	SpadeMaxAge time.Duration
+	// ? ClaimBonusOnLoad claims a bonus chest found while loading the channel points context.
+	ClaimBonusOnLoad bool
+	// ? DebugHTTP logs every request's operation, status and latency, without headers or bodies.
+	DebugHTTP bool
+	// ? MaxAuthFailures is how many consecutive unauthorized GQL responses halt betting and claiming; 0 disables the guard.
+	MaxAuthFailures int
+	// ? CommunityGoalRetries is how often a contribution that failed in transit is retried, with growing pauses.
+	CommunityGoalRetries int
+	// ? RecoverPanics logs a panic in a community goal retry instead of crashing the process.
+	RecoverPanics bool
+}
+
+func (s *TwitchSettings) Default() {
+	if s.MaxConcurrentGQL <= 0 {
+		s.MaxConcurrentGQL = 16
+	}
+	if s.MaxConcurrentWatch <= 0 {
+		s.MaxConcurrentWatch = 1
+	}
+	if s.SpadeMaxAge <= 0 {
+		s.SpadeMaxAge = time.Hour
+	}
 }
 
 type Twitch struct {
+	settings       TwitchSettings
 	userAgent      string
 	deviceID       string
 	clientSession  string
@@ -38,34 +89,73 @@ type Twitch struct {
 	twilightRegexp *regexp.Regexp
 	settingsRegex  *regexp.Regexp
 	spadeRegex     *regexp.Regexp
-	logger         debugLogger
+	logger         Logger
+	gqlSlots       chan struct{}
+	watchSlots     chan struct{}
+	spadeMu        sync.Mutex
+	sharedSpadeURL string
+	authFailures   int32
+	lastAuthOK     int64
+	reloginCh      chan struct{}
+	goalMu         sync.Mutex
+	goalPending    map[string]struct{}
+	rewardsMu      sync.Mutex
+	rewardNames    map[string][]string
+	momentMu       sync.Mutex
+	momentsSeen    map[string]struct{}
 }
 
 type ClaimedDrop struct {
+	InstanceID    string
+	RewardName    string
+	CampaignName  string
+	CurrentValue  int
+	RequiredValue int
+}
+
+type ExpiringDrop struct {
 	RewardName    string
 	CampaignName  string
+	EndsAt        time.Time
 	CurrentValue  int
 	RequiredValue int
 }
 
-func NewTwitch(username, userAgent, password string, logger debugLogger) (*Twitch, error) {
+func NewTwitch(username, userAgent, password string, logger Logger, settings TwitchSettings) (*Twitch, error) {
 	deviceID := randomString(32)
 	login, err := NewTwitchLogin(constants.ClientID, deviceID, username, userAgent, password)
 	if err != nil {
 		return nil, err
 	}
+	settings.Default()
+	client := login.Client()
+	if settings.DebugHTTP && logger != nil {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		// ? shared with TwitchLogin, so token checks and the device flow are logged as well
+		client.Transport = &debugTransport{base: base, logger: logger}
+	}
 
 	return &Twitch{
+		settings:       settings,
 		userAgent:      userAgent,
 		deviceID:       deviceID,
 		clientSession:  randomString(32),
 		clientVersion:  constants.ClientVersion,
 		twitchLogin:    login,
-		client:         login.Client(),
+		client:         client,
 		twilightRegexp: regexp.MustCompile(`window\.__twilightBuildID\s*=\s*"([0-9a-fA-F\-]{36})"`),
 		settingsRegex:  regexp.MustCompile(`(https://static\.twitchcdn\.net/config/settings.*?\.js|https://assets\.twitch\.tv/config/settings.*?\.js)`),
 		spadeRegex:     regexp.MustCompile(`"spade_url":"(.*?)"`),
 		logger:         logger,
+		gqlSlots:       make(chan struct{}, settings.MaxConcurrentGQL),
+		watchSlots:     make(chan struct{}, settings.MaxConcurrentWatch),
+		reloginCh:      make(chan struct{}, 1),
+		goalPending:    make(map[string]struct{}),
+		rewardNames:    make(map[string][]string),
+		momentsSeen:    make(map[string]struct{}),
 	}, nil
 }
 
@@ -77,6 +167,65 @@ func (t *Twitch) Login(username string) error {
 	return nil
 }
 
+// ? AuthHalted reports whether MaxAuthFailures consecutive GQL calls were rejected as unauthorized.
+// ? Loops that spend or claim points check it so a broken login stops acting instead of spinning.
+func (t *Twitch) AuthHalted() bool {
+	limit := t.settings.MaxAuthFailures
+	return limit > 0 && t.AuthFailures() >= limit
+}
+
+func (t *Twitch) AuthFailures() int {
+	return int(atomic.LoadInt32(&t.authFailures))
+}
+
+// ? RecheckAuth validates the stored token again and clears the failure counter when it works.
+func (t *Twitch) RecheckAuth() bool {
+	if !t.twitchLogin.checkLogin() {
+		return false
+	}
+	atomic.StoreInt32(&t.authFailures, 0)
+	return true
+}
+
+// ? concurrentSessionWindow is how recently the token must have worked for a rejection to be blamed on another session.
+const concurrentSessionWindow = 10 * time.Minute
+
+func (t *Twitch) recordAuthStatus(status int) {
+	switch {
+	case status == http.StatusUnauthorized:
+		n := atomic.AddInt32(&t.authFailures, 1)
+		if n == 1 {
+			if okAt := atomic.LoadInt64(&t.lastAuthOK); okAt > 0 && time.Since(time.Unix(0, okAt)) < concurrentSessionWindow {
+				t.logger.Errorf("GQL rejected the auth token that worked %s ago; another session (a browser login or a second miner on this account) may have invalidated it. Logging in again", time.Since(time.Unix(0, okAt)).Round(time.Second))
+				select {
+				case t.reloginCh <- struct{}{}:
+				default:
+				}
+			}
+		}
+		if int(n) == t.settings.MaxAuthFailures {
+			t.logger.Errorf("GQL rejected the auth token %d times in a row; betting and claiming are paused", n)
+		}
+	case status >= 200 && status < 300:
+		atomic.StoreInt32(&t.authFailures, 0)
+		atomic.StoreInt64(&t.lastAuthOK, time.Now().UnixNano())
+	}
+}
+
+// ? ReloginRequests fires when a token that just worked is rejected, which usually means a concurrent session.
+func (t *Twitch) ReloginRequests() <-chan struct{} {
+	return t.reloginCh
+}
+
+// ? Relogin keeps the stored token if it still validates, otherwise runs the login flow again and clears the failure counter.
+func (t *Twitch) Relogin(username string) error {
+	if err := t.Login(username); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&t.authFailures, 0)
+	return nil
+}
+
 func (t *Twitch) debugf(format string, args ...interface{}) {
 	if t.logger != nil && t.logger.DebugEnabled() {
 		t.logger.Debugf(format, args...)
@@ -119,16 +268,21 @@ func (t *Twitch) PostGQL(payload interface{}) (map[string]interface{}, error) {
 	req.Header.Set("X-Device-Id", t.deviceID)
 	req.Header.Set("Content-Type", "application/json")
 
+	// ? Bound in-flight GQL calls so parallel work can't trip Twitch's per-IP limits.
+	t.gqlSlots <- struct{}{}
 	resp, err := t.client.Do(req)
 	if err != nil {
+		<-t.gqlSlots
 		t.debugf("GQL request failed: %v", err)
 		return nil, err
 	}
 	respBody, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	<-t.gqlSlots
 	if err != nil {
 		return nil, err
 	}
+	t.recordAuthStatus(resp.StatusCode)
 	t.debugf("GQL %s | Status %d | Request: %s | Response: %s", operationName(payload), resp.StatusCode, strings.TrimSpace(string(body)), strings.TrimSpace(string(respBody)))
 	var result map[string]interface{}
 	if err := json.Unmarshal(respBody, &result); err != nil {
@@ -151,10 +305,16 @@ func (t *Twitch) GetChannelID(login string) (string, error) {
 	if s, ok := user.(string); ok && s != "" {
 		return s, nil
 	}
-	return "", fmt.Errorf("user %s not found", login)
+	return "", fmt.Errorf("%w: %s", ErrUserNotFound, login)
 }
 
-func (t *Twitch) GetFollowers(limit int, order entities.FollowersOrder) ([]string, error) {
+// ? Follow is one entry of the follow list; FollowedAt is zero when Twitch didn't report when the follow happened.
+type Follow struct {
+	Login      string
+	FollowedAt time.Time
+}
+
+func (t *Twitch) GetFollowers(limit int, order entities.FollowersOrder) ([]Follow, error) {
 	op := constants.GQLOperations.ChannelFollows
 	if op.Variables == nil {
 		op.Variables = map[string]interface{}{}
@@ -163,7 +323,7 @@ func (t *Twitch) GetFollowers(limit int, order entities.FollowersOrder) ([]strin
 	op.Variables["order"] = string(order)
 	hasNext := true
 	cursor := ""
-	var follows []string
+	var follows []Follow
 
 	for hasNext {
 		op.Variables["cursor"] = cursor
@@ -183,7 +343,15 @@ func (t *Twitch) GetFollowers(limit int, order entities.FollowersOrder) ([]strin
 			e := edge.(map[string]interface{})
 			node, _ := e["node"].(map[string]interface{})
 			login, _ := node["login"].(string)
-			follows = append(follows, strings.ToLower(login))
+			follow := Follow{Login: strings.ToLower(login)}
+			followedAt, _ := e["followedAt"].(string)
+			if followedAt == "" {
+				followedAt, _ = navigate(node, "self.follower.followedAt").(string)
+			}
+			if at, err := time.Parse(time.RFC3339, followedAt); err == nil {
+				follow.FollowedAt = at
+			}
+			follows = append(follows, follow)
 			if c, ok := e["cursor"].(string); ok {
 				cursor = c
 			}
@@ -193,8 +361,9 @@ func (t *Twitch) GetFollowers(limit int, order entities.FollowersOrder) ([]strin
 	return follows, nil
 }
 
-// ? LoadChannelPointsContext fetches points and claims any bonuses.
-func (t *Twitch) LoadChannelPointsContext(streamer *entities.Streamer) (int, error) {
+// ? LoadChannelPointsContext fetches points and, with ClaimBonusOnLoad and the streamer's ClaimBonus, claims a waiting bonus chest.
+// ? ChannelPoints is set to the pre-claim balance; the claim is returned so the caller can account for it like a PubSub gain.
+func (t *Twitch) LoadChannelPointsContext(streamer *entities.Streamer) (BonusClaim, error) {
 	op := constants.GQLOperations.ChannelPointsContext
 	if op.Variables == nil {
 		op.Variables = map[string]interface{}{}
@@ -202,16 +371,19 @@ func (t *Twitch) LoadChannelPointsContext(streamer *entities.Streamer) (int, err
 	op.Variables["channelLogin"] = streamer.Username
 	resp, err := t.PostGQL(op)
 	if err != nil {
-		return 0, err
+		return BonusClaim{}, err
 	}
 	channel := navigate(resp, "data.community.channel")
 	if channel == nil {
-		return 0, fmt.Errorf("channel missing for %s", streamer.Username)
+		return BonusClaim{}, fmt.Errorf("channel missing for %s", streamer.Username)
+	}
+	if name, ok := navigate(resp, "data.community.displayName").(string); ok && name != "" {
+		streamer.DisplayName = name
 	}
 	self := navigate(resp, "data.community.channel.self.communityPoints")
 	pointsData, _ := self.(map[string]interface{})
 	balance := int(fromFloat(pointsData["balance"]))
-	streamer.ChannelPoints = balance
+	streamer.SetChannelPoints(balance)
 	if active, ok := pointsData["activeMultipliers"].([]interface{}); ok {
 		multipliers := make([]map[string]interface{}, 0, len(active))
 		for _, item := range active {
@@ -225,29 +397,46 @@ func (t *Twitch) LoadChannelPointsContext(streamer *entities.Streamer) (int, err
 	}
 	if streamer.Settings.CommunityGoals {
 		goals := navigate(resp, "data.community.channel.communityPointsSettings.goals")
-		streamer.CommunityGoals = parseCommunityGoals(goals)
+		streamer.SetCommunityGoals(parseCommunityGoals(goals))
 		t.ContributeToCommunityGoals(streamer)
 	}
-	if available := navigate(resp, "data.community.channel.self.communityPoints.availableClaim"); available != nil {
+	var claim BonusClaim
+	available := navigate(resp, "data.community.channel.self.communityPoints.availableClaim")
+	if available != nil && t.settings.ClaimBonusOnLoad && !streamer.Settings.ClaimBonus {
+		t.debugf("Skip bonus claim for %s: claim_bonus is off", streamer.Username)
+	} else if available != nil && t.settings.ClaimBonusOnLoad {
 		if claimID, ok := navigate(available, "id").(string); ok && claimID != "" {
-			_ = t.ClaimBonus(streamer, claimID)
+			if claim, err = t.ClaimBonus(streamer, claimID); err != nil {
+				t.debugf("Claim bonus for %s on context load failed: %v", streamer.Username, err)
+			}
 		}
 	}
-	return balance, nil
+	return claim, nil
 }
 
 func (t *Twitch) CheckStreamerOnline(streamer *entities.Streamer) (bool, error) {
+	online, err := t.StreamerLive(streamer)
+	if err != nil {
+		return streamer.IsOnline, err
+	}
+	streamer.SetOnline(online)
+	if online {
+		streamer.OnlineAt = time.Now()
+	} else {
+		streamer.OfflineAt = time.Now()
+	}
+	return online, nil
+}
+
+// ? StreamerLive asks Twitch whether the channel is live without touching the streamer's presence fields.
+func (t *Twitch) StreamerLive(streamer *entities.Streamer) (bool, error) {
 	_, err := t.streamInfo(streamer.Username)
 	if err == ErrStreamerOffline {
-		streamer.IsOnline = false
-		streamer.OfflineAt = time.Now()
 		return false, nil
 	}
 	if err != nil {
-		return streamer.IsOnline, err
+		return false, err
 	}
-	streamer.IsOnline = true
-	streamer.OnlineAt = time.Now()
 	return true, nil
 }
 
@@ -274,10 +463,8 @@ func (t *Twitch) streamInfo(username string) (map[string]interface{}, error) {
 
 // ? UpdateStream refreshes metadata and payload required for minute-watched events.
 func (t *Twitch) UpdateStream(streamer *entities.Streamer) error {
-	if streamer.Stream == nil {
-		streamer.Stream = entities.NewStream()
-	}
-	if !streamer.Stream.UpdateRequired() {
+	streamer.EnsureStream()
+	if !streamer.StreamUpdateRequired() {
 		return nil
 	}
 	info, err := t.streamInfo(streamer.Username)
@@ -293,7 +480,7 @@ func (t *Twitch) UpdateStream(streamer *entities.Streamer) error {
 	game, _ := broadcastSettings["game"].(map[string]interface{})
 	tagsIface, _ := streamData["tags"].([]interface{})
 	viewers := int(fromFloat(streamData["viewersCount"]))
-	streamer.Stream.Update(
+	gameChanged := streamer.UpdateStream(
 		fmt.Sprint(streamData["id"]),
 		title,
 		game,
@@ -309,6 +496,10 @@ func (t *Twitch) UpdateStream(streamer *entities.Streamer) error {
 		"player":       "site",
 		"live":         true,
 		"channel":      streamer.Username,
+		"location":     "channel",
+		"logged_in":    true,
+		"hidden":       false,
+		"muted":        false,
 	}
 	if name, ok := game["name"].(string); ok && name != "" && streamer.Settings.ClaimDrops {
 		eventProps["game"] = name
@@ -317,8 +508,23 @@ func (t *Twitch) UpdateStream(streamer *entities.Streamer) error {
 		}
 		campaigns, err := t.CampaignIDsForStreamer(streamer)
 		if err == nil {
-			streamer.Stream.CampaignIDs = campaigns
+			streamer.SetCampaignIDs(campaigns)
+		} else if gameChanged {
+			// ? campaigns from the previous game no longer apply; don't hold a DROPS slot on stale data
+			streamer.SetCampaignIDs([]string{})
+		}
+		if gameChanged {
+			t.debugf("%s switched game to %q, %d drop campaign(s) available", streamer.Username, name, streamer.CampaignCount())
 		}
+	} else if count := streamer.CampaignCount(); count > 0 {
+		t.debugf("%s is no longer drop-eligible, clearing %d campaign(s)", streamer.Username, count)
+		streamer.SetCampaignIDs([]string{})
+	}
+	for key, value := range WatchProfiles[t.settings.WatchProfile] {
+		eventProps[key] = value
+	}
+	for key, value := range t.settings.SpadeExtraProps {
+		eventProps[key] = value
 	}
 	streamer.Stream.Payload = []map[string]interface{}{
 		{
@@ -329,56 +535,75 @@ func (t *Twitch) UpdateStream(streamer *entities.Streamer) error {
 	return nil
 }
 
+// ? GetSpadeURL resolves the minute-watched endpoint for a streamer. When extraction fails it falls back to the
+// ? last URL that worked for any channel, since Twitch serves the same spade endpoint to all of them.
 func (t *Twitch) GetSpadeURL(streamer *entities.Streamer) error {
-	if streamer.Stream == nil {
-		streamer.Stream = entities.NewStream()
-	}
-	headers := map[string]string{
-		"User-Agent": utils.UserAgents["Linux"]["FIREFOX"],
+	streamer.EnsureStream()
+	spadeURL, err := t.extractSpadeURL(streamer)
+	t.spadeMu.Lock()
+	if err == nil {
+		t.sharedSpadeURL = spadeURL
+	} else if t.sharedSpadeURL != "" {
+		t.debugf("Spade URL extraction for %s failed (%v), using the shared URL", streamer.Username, err)
+		spadeURL = t.sharedSpadeURL
+		err = nil
+	}
+	t.spadeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSpadeUnavailable, err)
 	}
+	streamer.Stream.SpadeURL = spadeURL
+	streamer.Stream.SpadeFetched = time.Now()
+	t.debugf("Spade URL for %s resolved to %s", streamer.Username, streamer.Stream.SpadeURL)
+	return nil
+}
+
+// ? extractSpadeURL looks for spade_url in the channel page first, then in the settings script it references.
+func (t *Twitch) extractSpadeURL(streamer *entities.Streamer) (string, error) {
 	pageURL := streamer.StreamerURL
 	if pageURL == "" {
 		pageURL = fmt.Sprintf("%s/%s", constants.URL, streamer.Username)
 	}
-	mainReq, _ := http.NewRequest(http.MethodGet, pageURL, nil)
-	for k, v := range headers {
-		mainReq.Header.Set(k, v)
-	}
-	resp, err := t.client.Do(mainReq)
+	body, status, err := t.fetchPage(pageURL)
 	if err != nil {
-		return err
+		return "", err
 	}
-	body, err := io.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return err
+	t.debugf("GetSpadeURL main page for %s status %d", streamer.Username, status)
+	if spade := t.spadeRegex.FindStringSubmatch(body); len(spade) >= 2 {
+		return spade[1], nil
 	}
-	t.debugf("GetSpadeURL main page for %s status %d", streamer.Username, resp.StatusCode)
-	match := t.settingsRegex.FindStringSubmatch(string(body))
+	match := t.settingsRegex.FindStringSubmatch(body)
 	if len(match) < 2 {
-		return errors.New("settings script not found")
+		return "", errors.New("settings script not found")
 	}
-	settingsReq, _ := http.NewRequest(http.MethodGet, match[1], nil)
-	for k, v := range headers {
-		settingsReq.Header.Set(k, v)
+	settingsBody, status, err := t.fetchPage(match[1])
+	if err != nil {
+		return "", err
+	}
+	t.debugf("GetSpadeURL settings for %s status %d", streamer.Username, status)
+	spade := t.spadeRegex.FindStringSubmatch(settingsBody)
+	if len(spade) < 2 {
+		return "", errors.New("spade url not found")
 	}
-	settingsResp, err := t.client.Do(settingsReq)
+	return spade[1], nil
+}
+
+func (t *Twitch) fetchPage(pageURL string) (string, int, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
 	if err != nil {
-		return err
+		return "", 0, err
 	}
-	defer settingsResp.Body.Close()
-	settingsBody, err := io.ReadAll(settingsResp.Body)
+	req.Header.Set("User-Agent", utils.UserAgents["Linux"]["FIREFOX"])
+	resp, err := t.client.Do(req)
 	if err != nil {
-		return err
+		return "", 0, err
 	}
-	t.debugf("GetSpadeURL settings for %s status %d", streamer.Username, settingsResp.StatusCode)
-	spade := t.spadeRegex.FindStringSubmatch(string(settingsBody))
-	if len(spade) < 2 {
-		return errors.New("spade url not found")
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", resp.StatusCode, err
 	}
-	streamer.Stream.SpadeURL = spade[1]
-	t.debugf("Spade URL for %s resolved to %s", streamer.Username, streamer.Stream.SpadeURL)
-	return nil
+	return string(body), resp.StatusCode, nil
 }
 
 func (t *Twitch) SendMinuteWatched(streamer *entities.Streamer) error {
@@ -389,6 +614,11 @@ func (t *Twitch) SendMinuteWatched(streamer *entities.Streamer) error {
 		if err := t.GetSpadeURL(streamer); err != nil {
 			return err
 		}
+	} else if time.Since(streamer.Stream.SpadeFetched) > t.settings.SpadeMaxAge {
+		// ? keep the old URL if the refresh fails; it may still be accepted
+		if err := t.GetSpadeURL(streamer); err != nil {
+			t.debugf("Spade URL refresh for %s failed, reusing the old one: %v", streamer.Username, err)
+		}
 	}
 	streamer.Stream.UpdateMinuteWatched()
 	payload, err := streamer.Stream.EncodePayload()
@@ -401,22 +631,36 @@ func (t *Twitch) SendMinuteWatched(streamer *entities.Streamer) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", t.userAgent)
 	t.debugf("Send minute watched payload to %s (%s)", streamer.Username, streamer.Stream.SpadeURL)
+	// ? Bound in-flight sends so more watchers or a short interval can't turn into bursts against spade.
+	t.watchSlots <- struct{}{}
 	resp, err := t.client.Do(req)
 	if err != nil {
+		<-t.watchSlots
+		streamer.Stream.SpadeURL = ""
 		return err
 	}
 	bodyBytes, _ := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	<-t.watchSlots
 	t.debugf("Minute watched response for %s: %d %s", streamer.Username, resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
 	if resp.StatusCode == http.StatusNoContent {
 		streamer.Stream.UpdateMinuteWatched()
+		streamer.CreditWatchTime(time.Now())
 		return nil
 	}
+	// ? force a fresh spade URL on the next attempt in case this one expired
+	streamer.Stream.SpadeURL = ""
 	return fmt.Errorf("minute watched failed: %d %s", resp.StatusCode, string(bodyBytes))
 }
 
 // ? ClaimBonus redeems the community points bonus (blue chest).
-func (t *Twitch) ClaimBonus(streamer *entities.Streamer, claimID string) error {
+// ? BonusClaim is what a bonus chest claim paid out; zero values mean the response didn't say.
+type BonusClaim struct {
+	Earned  int
+	Balance int
+}
+
+func (t *Twitch) ClaimBonus(streamer *entities.Streamer, claimID string) (BonusClaim, error) {
 	op := constants.GQLOperations.ClaimCommunityPoints
 	if op.Variables == nil {
 		op.Variables = map[string]interface{}{}
@@ -425,8 +669,14 @@ func (t *Twitch) ClaimBonus(streamer *entities.Streamer, claimID string) error {
 		"channelID": streamer.ChannelID,
 		"claimID":   claimID,
 	}
-	_, err := t.PostGQL(op)
-	return err
+	resp, err := t.PostGQL(op)
+	if err != nil {
+		return BonusClaim{}, err
+	}
+	return BonusClaim{
+		Earned:  int(fromFloat(navigate(resp, "data.claimCommunityPoints.claim.pointsEarnedTotal"))),
+		Balance: int(fromFloat(navigate(resp, "data.claimCommunityPoints.currentPoints"))),
+	}, nil
 }
 
 // ? ClaimMoment redeems a community moment callout.
@@ -443,6 +693,66 @@ func (t *Twitch) ClaimMoment(streamer *entities.Streamer, momentID string) error
 	return err
 }
 
+// ? ClaimMomentOnce claims a moment unless PubSub or the moment poller already claimed it this session, and reports
+// ? whether this call claimed it.
+func (t *Twitch) ClaimMomentOnce(streamer *entities.Streamer, momentID string) (bool, error) {
+	return t.claimMomentOnce(momentID, func() error { return t.ClaimMoment(streamer, momentID) })
+}
+
+// ? claimMomentOnce marks the moment before claiming so the lock isn't held over the request; a failed claim is
+// ? unmarked so the next activation or poll retries it.
+func (t *Twitch) claimMomentOnce(momentID string, claim func() error) (bool, error) {
+	if momentID == "" {
+		return false, nil
+	}
+	t.momentMu.Lock()
+	if _, ok := t.momentsSeen[momentID]; ok {
+		t.momentMu.Unlock()
+		return false, nil
+	}
+	t.momentsSeen[momentID] = struct{}{}
+	t.momentMu.Unlock()
+	if err := claim(); err != nil {
+		t.momentMu.Lock()
+		delete(t.momentsSeen, momentID)
+		t.momentMu.Unlock()
+		return false, err
+	}
+	return true, nil
+}
+
+// ? ErrMomentsQueryUnset is returned by ClaimableMoments until gql_overrides.json supplies the CommunityMoments hash.
+var ErrMomentsQueryUnset = errors.New("no persisted query hash for CommunityMoments")
+
+// ? ClaimableMoments lists the IDs of the channel's moments the user can still claim.
+func (t *Twitch) ClaimableMoments(streamer *entities.Streamer) ([]string, error) {
+	op := constants.GQLOperations.CommunityMoments
+	if op.Extensions.PersistedQuery.Sha256Hash == "" {
+		return nil, ErrMomentsQueryUnset
+	}
+	op.Variables = map[string]interface{}{"channelID": streamer.ChannelID}
+	resp, err := t.PostGQL(op)
+	if err != nil {
+		return nil, err
+	}
+	return claimableMomentIDs(resp), nil
+}
+
+func claimableMomentIDs(resp map[string]interface{}) []string {
+	moments, _ := navigate(resp, "data.channel.communityMoments").([]interface{})
+	ids := make([]string, 0, len(moments))
+	for _, raw := range moments {
+		moment, ok := raw.(map[string]interface{})
+		if !ok || navigate(moment, "self.isClaimed") == true {
+			continue
+		}
+		if id := stringOrDefault(moment["id"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // ? JoinRaid follows a raid target to mimic viewer behavior.
 func (t *Twitch) JoinRaid(streamer *entities.Streamer, raidID string) error {
 	if raidID == "" {
@@ -475,8 +785,82 @@ func (t *Twitch) MakePrediction(event *PredictionEvent) error {
 		"points":        event.Decision.Amount,
 		"transactionID": randomHex(16),
 	}
-	_, err := t.PostGQL(op)
-	return err
+	resp, err := t.PostGQL(op)
+	if err != nil {
+		return err
+	}
+	if code, ok := navigate(resp, "data.makePrediction.error.code").(string); ok && code != "" {
+		return fmt.Errorf("make prediction rejected: %s", code)
+	}
+	return nil
+}
+
+// ? ConfirmPrediction looks the event up among the channel's active and locked predictions and returns the stake
+// ? and outcome Twitch recorded for the user.
+func (t *Twitch) ConfirmPrediction(event *PredictionEvent) (points int, outcomeID string, err error) {
+	prediction, _, err := t.userPrediction(event)
+	if err != nil {
+		return 0, "", err
+	}
+	points = int(fromFloat(prediction["points"]))
+	if points <= 0 {
+		return 0, "", fmt.Errorf("no stake recorded on event %s", event.EventID)
+	}
+	return points, stringOrDefault(navigate(prediction, "outcome.id")), nil
+}
+
+// ? PredictionResult reads the user's result for an event over GQL, shaped like a PubSub prediction-result. It
+// ? returns nil until the event is resolved or canceled, and an error once Twitch no longer lists the event.
+func (t *Twitch) PredictionResult(event *PredictionEvent) (map[string]interface{}, error) {
+	prediction, status, err := t.userPrediction(event)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToUpper(status) {
+	case "CANCELED", "CANCELLED":
+		return map[string]interface{}{"type": "REFUND"}, nil
+	case "RESOLVED":
+		resultType := stringOrDefault(navigate(prediction, "result.type"))
+		if resultType == "" {
+			return nil, nil
+		}
+		return map[string]interface{}{"type": resultType, "points_won": navigate(prediction, "result.pointsWon")}, nil
+	}
+	return nil, nil
+}
+
+// ? userPrediction finds the event among the channel's active and locked predictions and returns the user's
+// ? prediction on it together with the event status.
+func (t *Twitch) userPrediction(event *PredictionEvent) (map[string]interface{}, string, error) {
+	if event == nil || event.Streamer == nil {
+		return nil, "", fmt.Errorf("nil prediction event")
+	}
+	op := constants.GQLOperations.ChannelPointsPredictionContext
+	variables := map[string]interface{}{}
+	for k, v := range op.Variables {
+		variables[k] = v
+	}
+	variables["channelLogin"] = event.Streamer.Username
+	op.Variables = variables
+	resp, err := t.PostGQL(op)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, path := range []string{"data.community.channel.activePredictionEvents", "data.community.channel.lockedPredictionEvents"} {
+		events, _ := navigate(resp, path).([]interface{})
+		for _, raw := range events {
+			ev, ok := raw.(map[string]interface{})
+			if !ok || stringOrDefault(ev["id"]) != event.EventID {
+				continue
+			}
+			prediction, ok := navigate(ev, "self.prediction").(map[string]interface{})
+			if !ok {
+				return nil, "", fmt.Errorf("no prediction recorded on event %s", event.EventID)
+			}
+			return prediction, stringOrDefault(ev["status"]), nil
+		}
+	}
+	return nil, "", fmt.Errorf("event %s not found on %s", event.EventID, event.Streamer.Username)
 }
 
 // ? ClaimDrop claims a single drop instance.
@@ -500,6 +884,12 @@ func (t *Twitch) ClaimDrop(dropInstanceID string) (bool, error) {
 }
 
 func (t *Twitch) ClaimAllDropsFromInventory() ([]ClaimedDrop, error) {
+	return t.ClaimDropsFromInventory(nil)
+}
+
+// ? ClaimDropsFromInventory claims finished drops, limited to campaigns whose name contains one of campaigns
+// ? (case-insensitive) when the list is non-empty. Drops of other campaigns are logged and left for later.
+func (t *Twitch) ClaimDropsFromInventory(campaigns []string) ([]ClaimedDrop, error) {
 	var claimedDrops []ClaimedDrop
 	inv := t.inventory()
 	if inv == nil {
@@ -513,6 +903,7 @@ func (t *Twitch) ClaimAllDropsFromInventory() ([]ClaimedDrop, error) {
 			continue
 		}
 		campaignName := campaignNameFromInventory(campaign)
+		campaignAllowed := len(campaigns) == 0 || containsFold(campaigns, campaignName)
 		td, _ := campaign["timeBasedDrops"].([]interface{})
 		for _, d := range td {
 			inner, ok := d.(map[string]interface{})
@@ -529,6 +920,14 @@ func (t *Twitch) ClaimAllDropsFromInventory() ([]ClaimedDrop, error) {
 				continue
 			}
 			rewardName := rewardNameFromInventory(inner)
+			if !campaignAllowed {
+				t.logger.Printf("Skip drop %s (%s): campaign not listed in claim_drops_startup", rewardName, campaignName)
+				continue
+			}
+			if !t.rewardAllowed(rewardName) {
+				t.debugf("Skip drop %s (%s): not in drops_reward_whitelist", rewardName, campaignName)
+				continue
+			}
 			current, required := dropProgress(inner, self)
 			ok, err := t.ClaimDrop(id)
 			if err != nil {
@@ -539,6 +938,7 @@ func (t *Twitch) ClaimAllDropsFromInventory() ([]ClaimedDrop, error) {
 			}
 			if ok {
 				claimedDrops = append(claimedDrops, ClaimedDrop{
+					InstanceID:    id,
 					RewardName:    rewardName,
 					CampaignName:  campaignName,
 					CurrentValue:  current,
@@ -553,12 +953,12 @@ func (t *Twitch) ClaimAllDropsFromInventory() ([]ClaimedDrop, error) {
 
 // ? ContributeToCommunityGoals mirrors the site behavior by spending points into active community goals.
 func (t *Twitch) ContributeToCommunityGoals(streamer *entities.Streamer) {
-	if streamer == nil || !streamer.Settings.CommunityGoals || len(streamer.CommunityGoals) == 0 {
+	if streamer == nil || !streamer.Settings.CommunityGoals {
 		return
 	}
 	hasActive := false
-	for _, goal := range streamer.CommunityGoals {
-		if goal != nil && goal.Status == "STARTED" && goal.IsInStock {
+	for _, goal := range streamer.CommunityGoalList() {
+		if goal.Active() {
 			hasActive = true
 			break
 		}
@@ -566,7 +966,38 @@ func (t *Twitch) ContributeToCommunityGoals(streamer *entities.Streamer) {
 	if !hasActive {
 		return
 	}
+	spendable := streamer.SpendablePoints()
+	if spendable <= 0 {
+		if streamer.Settings.PointsReserve > 0 && t.logger != nil {
+			t.logger.Printf("Skip community goal contribution for %s: balance %d within points_reserve %d", streamer.Username, streamer.ChannelPoints, streamer.Settings.PointsReserve)
+		}
+		return
+	}
 
+	contributed, err := t.userGoalContributions(streamer)
+	if err != nil {
+		return
+	}
+	for goalID, userPoints := range contributed {
+		goal, ok := streamer.CommunityGoal(goalID)
+		if !ok {
+			continue
+		}
+		userLeft := goal.PerStreamUserMaximumContribution - userPoints
+		amount := minInt(goal.AmountLeft(), userLeft, streamer.SpendablePoints())
+		if amount <= 0 {
+			continue
+		}
+		if !t.startGoalContribution(streamer, goalID) {
+			t.debugf("Community goal %s for %s already has a contribution pending", goalID, streamer.Username)
+			continue
+		}
+		t.contributeWithRetry(streamer, goalID, amount, 0)
+	}
+}
+
+// ? userGoalContributions returns the points the user contributed to each of the channel's goals this stream.
+func (t *Twitch) userGoalContributions(streamer *entities.Streamer) (map[string]int, error) {
 	op := constants.GQLOperations.UserPointsContribution
 	if op.Variables == nil {
 		op.Variables = map[string]interface{}{}
@@ -574,34 +1005,119 @@ func (t *Twitch) ContributeToCommunityGoals(streamer *entities.Streamer) {
 	op.Variables["channelLogin"] = streamer.Username
 	resp, err := t.PostGQL(op)
 	if err != nil {
-		return
+		return nil, err
 	}
-	contribs := navigate(resp, "data.user.channel.self.communityPoints.goalContributions")
-	arr, ok := contribs.([]interface{})
+	arr, ok := navigate(resp, "data.user.channel.self.communityPoints.goalContributions").([]interface{})
 	if !ok {
-		return
+		return nil, fmt.Errorf("goal contributions missing for %s", streamer.Username)
 	}
+	contributed := make(map[string]int, len(arr))
 	for _, raw := range arr {
 		goalContribution, ok := raw.(map[string]interface{})
 		if !ok {
 			continue
 		}
 		goalData, _ := goalContribution["goal"].(map[string]interface{})
-		goalID, _ := goalData["id"].(string)
-		if goalID == "" {
-			continue
+		if goalID, _ := goalData["id"].(string); goalID != "" {
+			contributed[goalID] = int(fromFloat(goalContribution["userPointsContributedThisStream"]))
 		}
-		goal := streamer.CommunityGoals[goalID]
-		if goal == nil {
-			continue
+	}
+	return contributed, nil
+}
+
+// ? startGoalContribution marks a contribution to the goal as in progress, or reports false when one already is;
+// ? goal updates keep arriving while a retry waits, and a second chain would contribute twice.
+func (t *Twitch) startGoalContribution(streamer *entities.Streamer, goalID string) bool {
+	key := streamer.ChannelID + "/" + goalID
+	t.goalMu.Lock()
+	defer t.goalMu.Unlock()
+	if _, ok := t.goalPending[key]; ok {
+		return false
+	}
+	t.goalPending[key] = struct{}{}
+	return true
+}
+
+func (t *Twitch) finishGoalContribution(streamer *entities.Streamer, goalID string) {
+	t.goalMu.Lock()
+	defer t.goalMu.Unlock()
+	delete(t.goalPending, streamer.ChannelID+"/"+goalID)
+}
+
+// ? communityGoalRetryDelay is the pause before the first retry; each further retry waits three times longer.
+const communityGoalRetryDelay = 10 * time.Second
+
+// ? errGoalRejected marks a contribution Twitch answered with an error; retrying it would fail the same way.
+var errGoalRejected = errors.New("contribution rejected")
+
+// ? contributeWithRetry sends a contribution and, if the request itself failed, schedules a retry in the background.
+// ? Before every retry the goal must still be running and in stock, and the amount shrinks to what is left of the goal,
+// ? of the user's per-stream maximum (a failed request may have gone through) and of the spendable balance.
+// ? The caller has claimed the goal with startGoalContribution; the chain releases it when it ends.
+func (t *Twitch) contributeWithRetry(streamer *entities.Streamer, goalID string, amount, attempt int) {
+	retry := false
+	defer func() {
+		if !retry {
+			t.finishGoalContribution(streamer, goalID)
 		}
-		userPoints := int(fromFloat(goalContribution["userPointsContributedThisStream"]))
-		userLeft := goal.PerStreamUserMaximumContribution - userPoints
-		amount := minInt(goal.AmountLeft(), userLeft, streamer.ChannelPoints)
-		if amount > 0 {
-			_ = t.ContributeToCommunityGoal(streamer, goalID, goal.Title, amount)
+	}()
+	goal, ok := streamer.CommunityGoal(goalID)
+	if !ok || !goal.Active() {
+		if attempt > 0 && t.logger != nil {
+			t.logger.Printf("Community goal %s for %s ended before the retry; skipping", goalID, streamer.Username)
 		}
+		return
 	}
+	var err error
+	if attempt > 0 {
+		var contributed map[string]int
+		if contributed, err = t.userGoalContributions(streamer); err == nil {
+			amount = minInt(amount, goal.PerStreamUserMaximumContribution-contributed[goalID])
+		}
+	}
+	if err == nil {
+		amount = minInt(amount, goal.AmountLeft(), streamer.SpendablePoints())
+		if amount <= 0 {
+			return
+		}
+		err = t.ContributeToCommunityGoal(streamer, goalID, goal.Title, amount)
+	}
+	if err == nil {
+		recordHistory(streamer, entities.ReasonCommunityGoal, -amount)
+		if t.logger != nil {
+			t.logger.EmojiPrintf(":moneybag:", "Contributed %d points to %s's community goal %q", amount, streamer.Username, goal.Title)
+		}
+		return
+	}
+	if errors.Is(err, errGoalRejected) || attempt >= t.settings.CommunityGoalRetries {
+		if t.logger != nil {
+			t.logger.Errorf("community goal %q for %s: %v", goal.Title, streamer.Username, err)
+		}
+		return
+	}
+	delay := communityGoalRetryDelay
+	for i := 0; i < attempt; i++ {
+		delay *= 3
+	}
+	if t.logger != nil {
+		t.logger.Printf("Community goal contribution for %s failed (%v); retrying in %s", streamer.Username, err, delay)
+	}
+	retry = true
+	time.AfterFunc(delay, func() {
+		t.recovered("community goal retry", func() { t.contributeWithRetry(streamer, goalID, amount, attempt+1) })
+	})
+}
+
+// ? recovered runs fn from a timer goroutine, logging a panic instead of crashing when RecoverPanics is set.
+func (t *Twitch) recovered(name string, fn func()) {
+	if t.settings.RecoverPanics {
+		defer func() {
+			if r := recover(); r != nil && t.logger != nil {
+				t.logger.Errorf("%s panicked: %v\n%s", name, r, debug.Stack())
+			}
+		}()
+	}
+	fn()
 }
 
 // ? ContributeToCommunityGoal sends a single contribution transaction.
@@ -624,17 +1140,69 @@ func (t *Twitch) ContributeToCommunityGoal(streamer *entities.Streamer, goalID,
 		return err
 	}
 	if errVal := navigate(resp, "data.contributeCommunityPointsCommunityGoal.error"); errVal != nil {
+		if errMap, ok := errVal.(map[string]interface{}); ok {
+			errVal = errMap["code"]
+		}
 		if errStr, ok := errVal.(string); ok && errStr != "" {
-			return fmt.Errorf("unable to contribute to %s: %s", title, errStr)
+			return fmt.Errorf("%w: unable to contribute to %s: %s", errGoalRejected, title, errStr)
 		}
 	}
-	streamer.ChannelPoints -= amount
-	if streamer.ChannelPoints < 0 {
-		streamer.ChannelPoints = 0
-	}
+	streamer.DebitChannelPoints(amount)
 	return nil
 }
 
+// ? ExpiringDrops lists started but unfinished drops whose campaign ends within the given window.
+func (t *Twitch) ExpiringDrops(within time.Duration) ([]ExpiringDrop, error) {
+	inv := t.inventory()
+	if inv == nil {
+		return nil, errors.New("inventory unavailable")
+	}
+	now := time.Now()
+	var expiring []ExpiringDrop
+	active, _ := inv["dropCampaignsInProgress"].([]interface{})
+	for _, c := range active {
+		campaign, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		endsAt, err := time.Parse(time.RFC3339, stringOrDefault(campaign["endAt"]))
+		if err != nil || endsAt.Before(now) || endsAt.Sub(now) > within {
+			continue
+		}
+		campaignName := campaignNameFromInventory(campaign)
+		td, _ := campaign["timeBasedDrops"].([]interface{})
+		for _, d := range td {
+			inner, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			self, _ := inner["self"].(map[string]interface{})
+			if self == nil {
+				continue
+			}
+			if claimed, _ := self["isClaimed"].(bool); claimed {
+				continue
+			}
+			current, required := dropProgress(inner, self)
+			if current <= 0 || required <= 0 || current >= required {
+				continue
+			}
+			rewardName := rewardNameFromInventory(inner)
+			if !t.rewardAllowed(rewardName) {
+				continue
+			}
+			expiring = append(expiring, ExpiringDrop{
+				RewardName:    rewardName,
+				CampaignName:  campaignName,
+				EndsAt:        endsAt,
+				CurrentValue:  current,
+				RequiredValue: required,
+			})
+		}
+	}
+	return expiring, nil
+}
+
 func campaignNameFromInventory(campaign map[string]interface{}) string {
 	if campaign == nil {
 		return ""
@@ -660,6 +1228,12 @@ func rewardNameFromInventory(drop map[string]interface{}) string {
 			return name
 		}
 	}
+	// ? time-based drops carry their own name too; the benefit is the reward
+	if edges, _ := drop["benefitEdges"].([]interface{}); len(edges) > 0 {
+		if name, _ := navigate(edges[0], "benefit.name").(string); name != "" {
+			return name
+		}
+	}
 	if name := mapStringValue(drop, "name", "displayName"); name != "" {
 		return name
 	}
@@ -722,13 +1296,96 @@ func (t *Twitch) CampaignIDsForStreamer(streamer *entities.Streamer) ([]string,
 	arr := cams.([]interface{})
 	var res []string
 	for _, c := range arr {
-		if id, ok := c.(map[string]interface{})["id"].(string); ok {
+		campaign, _ := c.(map[string]interface{})
+		if !t.campaignHasAllowedReward(campaign) {
+			continue
+		}
+		if id, ok := campaign["id"].(string); ok {
 			res = append(res, id)
 		}
 	}
 	return res, nil
 }
 
+func (t *Twitch) rewardAllowed(name string) bool {
+	if len(t.settings.DropsRewardWhitelist) == 0 {
+		return true
+	}
+	return containsFold(t.settings.DropsRewardWhitelist, name)
+}
+
+// ? containsFold reports whether name contains any non-empty entry of list, ignoring case.
+func containsFold(list []string, name string) bool {
+	lower := strings.ToLower(name)
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry != "" && strings.Contains(lower, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// ? campaignHasAllowedReward keeps campaigns whose reward names are unknown so the whitelist never hides them by accident.
+func (t *Twitch) campaignHasAllowedReward(campaign map[string]interface{}) bool {
+	if len(t.settings.DropsRewardWhitelist) == 0 {
+		return true
+	}
+	names := campaignRewardNames(campaign)
+	if len(names) == 0 {
+		// ? the available-drops listing carries no rewards; DropCampaignDetails does
+		names = t.campaignDetailsRewardNames(stringOrDefault(campaign["id"]))
+	}
+	for _, name := range names {
+		if t.rewardAllowed(name) {
+			return true
+		}
+	}
+	return len(names) == 0
+}
+
+// ? campaignRewardNames lists the reward names of a campaign's time-based drops.
+func campaignRewardNames(campaign map[string]interface{}) []string {
+	drops, _ := campaign["timeBasedDrops"].([]interface{})
+	var names []string
+	for _, d := range drops {
+		if name := rewardNameFromInventory(asMap(d)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ? campaignDetailsRewardNames loads a campaign's reward names once through DropCampaignDetails; rewards don't change
+// ? during a campaign, so the answer is kept for the session. A failed lookup returns nil and is tried again later.
+func (t *Twitch) campaignDetailsRewardNames(campaignID string) []string {
+	if campaignID == "" {
+		return nil
+	}
+	t.rewardsMu.Lock()
+	names, ok := t.rewardNames[campaignID]
+	t.rewardsMu.Unlock()
+	if ok {
+		return names
+	}
+	op := constants.GQLOperations.DropCampaignDetails
+	op.Variables = map[string]interface{}{"dropID": campaignID, "channelLogin": t.twitchLogin.Username}
+	resp, err := t.PostGQL(op)
+	if err != nil {
+		t.debugf("Drop campaign details for %s: %v", campaignID, err)
+		return nil
+	}
+	campaign, ok := navigate(resp, "data.user.dropCampaign").(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	names = campaignRewardNames(campaign)
+	t.rewardsMu.Lock()
+	t.rewardNames[campaignID] = names
+	t.rewardsMu.Unlock()
+	return names
+}
+
 func parseCommunityGoals(goals interface{}) map[string]*entities.CommunityGoal {
 	arr, ok := goals.([]interface{})
 	if !ok {
@@ -757,6 +1414,11 @@ func (t *Twitch) inventory() map[string]interface{} {
 	return inv.(map[string]interface{})
 }
 
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
 func operationName(payload interface{}) string {
 	switch p := payload.(type) {
 	case map[string]interface{}:
@@ -817,15 +1479,21 @@ func randomInt(min, max int) int {
 	return min + int(nBig.Int64())
 }
 
+// ? fromFloat also parses numeric strings, which some Twitch payloads use for balances and point gains.
 func fromFloat(v interface{}) float64 {
 	switch n := v.(type) {
 	case float64:
 		return n
 	case int:
 		return float64(n)
+	case int64:
+		return float64(n)
 	case json.Number:
 		f, _ := n.Float64()
 		return f
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
 	default:
 		return 0
 	}
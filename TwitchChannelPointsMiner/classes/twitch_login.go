@@ -30,6 +30,8 @@ type TwitchLogin struct {
 	client *http.Client
 	userID string
 	mu     sync.Mutex
+	// ? lock is held from the first Login until the process exits; see lockCookies.
+	lock *os.File
 }
 
 type persistedCookie struct {
@@ -42,7 +44,7 @@ type cookieStore map[string]persistedCookie
 
 func NewTwitchLogin(clientID, deviceID, username, userAgent, password string) (*TwitchLogin, error) {
 	jar, _ := cookiejar.New(nil)
-	client := &http.Client{Jar: jar, Timeout: 30 * time.Second}
+	client := &http.Client{Jar: jar, Timeout: 30 * time.Second, Transport: utils.SharedTransport()}
 	return &TwitchLogin{
 		ClientID:  clientID,
 		DeviceID:  deviceID,
@@ -56,6 +58,13 @@ func NewTwitchLogin(clientID, deviceID, username, userAgent, password string) (*
 func (t *TwitchLogin) Client() *http.Client { return t.client }
 
 func (t *TwitchLogin) Login(cookiesPath string) error {
+	if t.lock == nil {
+		lock, err := lockCookies(cookiesPath)
+		if err != nil {
+			return err
+		}
+		t.lock = lock
+	}
 	if err := t.loadCookies(cookiesPath); err == nil && t.Token != "" {
 		if ok := t.checkLogin(); ok {
 			return nil
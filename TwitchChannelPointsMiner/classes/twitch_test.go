@@ -0,0 +1,174 @@
+package classes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestGoalContributionPending(t *testing.T) {
+	tw := &Twitch{goalPending: make(map[string]struct{})}
+	a := &entities.Streamer{Username: "a", ChannelID: "1"}
+	b := &entities.Streamer{Username: "b", ChannelID: "2"}
+	if !tw.startGoalContribution(a, "goal") {
+		t.Fatal("first contribution refused")
+	}
+	if tw.startGoalContribution(a, "goal") {
+		t.Fatal("second chain started while the first is pending")
+	}
+	if !tw.startGoalContribution(b, "goal") || !tw.startGoalContribution(a, "other") {
+		t.Fatal("pending goal blocked another streamer or goal")
+	}
+	tw.finishGoalContribution(a, "goal")
+	if !tw.startGoalContribution(a, "goal") {
+		t.Fatal("goal still pending after the chain ended")
+	}
+}
+
+func TestCommunityGoalCopy(t *testing.T) {
+	s := &entities.Streamer{}
+	s.SetCommunityGoal(&entities.CommunityGoal{ID: "goal", Status: "STARTED", IsInStock: true, AmountNeeded: 100})
+	goal, ok := s.CommunityGoal("goal")
+	if !ok || !goal.Active() {
+		t.Fatalf("CommunityGoal() = %+v, %v", goal, ok)
+	}
+	s.SetCommunityGoal(&entities.CommunityGoal{ID: "goal", Status: "ENDED"})
+	if !goal.Active() || goal.AmountLeft() != 100 {
+		t.Fatal("the copy changed with the stored goal")
+	}
+	s.DeleteCommunityGoal("goal")
+	if _, ok := s.CommunityGoal("goal"); ok {
+		t.Fatal("deleted goal still returned")
+	}
+}
+
+func TestCampaignRefreshAfterGameSwitch(t *testing.T) {
+	s := &entities.Streamer{Username: "a"}
+	if s.SetStreamGame(map[string]interface{}{"id": "1"}) {
+		t.Fatal("game recorded before the stream was loaded")
+	}
+	s.EnsureStream()
+	s.SetStreamGame(map[string]interface{}{"id": "1", "displayName": "First"})
+	s.SetStreamGame(map[string]interface{}{"id": "2", "displayName": "Second"})
+	if s.SetCampaignIDsForGame("1", []string{"stale"}) {
+		t.Fatal("a refresh for the previous game overwrote the campaigns")
+	}
+	if !s.SetCampaignIDsForGame("2", []string{"c1", "c2"}) || s.CampaignCount() != 2 {
+		t.Fatalf("campaigns for the current game not stored, count %d", s.CampaignCount())
+	}
+	if got := s.StreamGameName(); got != "Second" {
+		t.Errorf("StreamGameName() = %q", got)
+	}
+	if !s.StreamUpdateRequired() {
+		t.Error("a game switch did not force a stream refresh")
+	}
+}
+
+func TestCampaignHasAllowedReward(t *testing.T) {
+	// ? abridged DropsHighlightService_AvailableDrops and DropCampaignDetails responses
+	const available = `{"data":{"channel":{"id":"1","viewerDropCampaigns":[
+		{"id":"wanted","name":"Skins","game":{"id":"9","name":"Game"}},
+		{"id":"unwanted","name":"Sprays","game":{"id":"9","name":"Game"}},
+		{"id":"unknown","name":"Mystery","game":{"id":"9","name":"Game"}}]}}}`
+	const details = `{"data":{"user":{"id":"42","dropCampaign":{"id":"wanted","name":"Skins","timeBasedDrops":[
+		{"id":"d1","name":"Watch 1 hour","requiredMinutesWatched":60,"benefitEdges":[{"benefit":{"id":"b1","name":"Golden Skin"},"entitlementLimit":1}]},
+		{"id":"d2","name":"Watch 2 hours","requiredMinutesWatched":120,"benefitEdges":[{"benefit":{"id":"b2","name":"Silver Skin"},"entitlementLimit":1}]}]}}}}`
+	const unwantedDetails = `{"data":{"user":{"id":"42","dropCampaign":{"id":"unwanted","timeBasedDrops":[
+		{"id":"d3","name":"Watch 1 hour","benefitEdges":[{"benefit":{"id":"b3","name":"Spray"}}]}]}}}}`
+
+	decode := func(s string) map[string]interface{} {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tw := &Twitch{
+		settings:    TwitchSettings{DropsRewardWhitelist: []string{"skin"}},
+		rewardNames: make(map[string][]string),
+	}
+	for _, raw := range []string{details, unwantedDetails} {
+		campaign := navigate(decode(raw), "data.user.dropCampaign").(map[string]interface{})
+		tw.rewardNames[campaign["id"].(string)] = campaignRewardNames(campaign)
+	}
+	if got := tw.rewardNames["wanted"]; len(got) != 2 || got[0] != "Golden Skin" {
+		t.Fatalf("reward names = %v, want the benefit names", got)
+	}
+	// ? "unknown" has no cached details; stub the failed lookup so the test stays offline
+	tw.rewardNames["unknown"] = nil
+
+	want := map[string]bool{"wanted": true, "unwanted": false, "unknown": true}
+	for _, raw := range navigate(decode(available), "data.channel.viewerDropCampaigns").([]interface{}) {
+		campaign := raw.(map[string]interface{})
+		id := campaign["id"].(string)
+		if got := tw.campaignHasAllowedReward(campaign); got != want[id] {
+			t.Errorf("campaignHasAllowedReward(%s) = %v, want %v", id, got, want[id])
+		}
+	}
+}
+
+func TestClaimMomentOnce(t *testing.T) {
+	tw := &Twitch{momentsSeen: make(map[string]struct{})}
+	if _, err := tw.claimMomentOnce("m1", func() error { return errors.New("offline") }); err == nil {
+		t.Fatal("claim error not returned")
+	}
+
+	var mu sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	claim := func() error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return nil
+	}
+	// ? PubSub and the poller race on the same moment; the failed attempt above must not block either
+	var wg sync.WaitGroup
+	results := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, _ := tw.claimMomentOnce("m1", claim)
+			results <- claimed
+		}()
+	}
+	for done := false; !done; {
+		tw.momentMu.Lock()
+		_, done = tw.momentsSeen["m1"]
+		tw.momentMu.Unlock()
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+	won := 0
+	for claimed := range results {
+		if claimed {
+			won++
+		}
+	}
+	if won != 1 || calls != 1 {
+		t.Errorf("%d claims reported, %d requests sent; want 1 and 1", won, calls)
+	}
+}
+
+func TestClaimableMomentIDs(t *testing.T) {
+	var resp map[string]interface{}
+	body := `{"data":{"channel":{"communityMoments":[
+		{"id":"open","self":{"isClaimed":false}},
+		{"id":"taken","self":{"isClaimed":true}},
+		{"id":"no-self"},
+		{"id":""}
+	]}}}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := claimableMomentIDs(resp), []string{"open", "no-self"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("claimableMomentIDs() = %v, want %v", got, want)
+	}
+}
@@ -46,6 +46,7 @@ var GQLOperations = struct {
 	ModViewChannelQuery                    GQLPersistedOperation
 	Inventory                              GQLPersistedOperation
 	MakePrediction                         GQLPersistedOperation
+	ChannelPointsPredictionContext         GQLPersistedOperation
 	ViewerDropsDashboard                   GQLPersistedOperation
 	DropCampaignDetails                    GQLPersistedOperation
 	DropsHighlightServiceAvailable         GQLPersistedOperation
@@ -54,6 +55,7 @@ var GQLOperations = struct {
 	ChannelFollows                         GQLPersistedOperation
 	UserPointsContribution                 GQLPersistedOperation
 	ContributeCommunityPointsCommunityGoal GQLPersistedOperation
+	CommunityMoments                       GQLPersistedOperation
 }{
 	URL:                          "https://gql.twitch.tv/gql",
 	IntegrityURL:                 "https://gql.twitch.tv/integrity",
@@ -70,6 +72,7 @@ var GQLOperations = struct {
 		"fetchRewardCampaigns": true,
 	}),
 	MakePrediction:                 newPersistedOperation("MakePrediction", "b44682ecc88358817009f20e69d75081b1e58825bb40aa53d5dbadcc17c881d8", nil),
+	ChannelPointsPredictionContext: newPersistedOperation("ChannelPointsPredictionContext", "beb846598256b75bd7c1fe54a80431335996153e358ca9c7837ce7bb83d7d383", map[string]interface{}{"count": 1}),
 	ViewerDropsDashboard:           newPersistedOperation("ViewerDropsDashboard", "5a4da2ab3d5b47c9f9ce864e727b2cb346af1e3ea8b897fe8f704a97ff017619", map[string]interface{}{"fetchRewardCampaigns": true}),
 	DropCampaignDetails:            newPersistedOperation("DropCampaignDetails", "f6396f5ffdde867a8f6f6da18286e4baf02e5b98d14689a69b5af320a4c7b7b8", nil),
 	DropsHighlightServiceAvailable: newPersistedOperation("DropsHighlightService_AvailableDrops", "9a62a09bce5b53e26e64a671e530bc599cb6aab1e5ba3cbd5d85966d3940716f", nil),
@@ -93,6 +96,8 @@ var GQLOperations = struct {
 	}),
 	UserPointsContribution:                 newPersistedOperation("UserPointsContribution", "23ff2c2d60708379131178742327ead913b93b1bd6f665517a6d9085b73f661f", nil),
 	ContributeCommunityPointsCommunityGoal: newPersistedOperation("ContributeCommunityPointsCommunityGoal", "5774f0ea5d89587d73021a2e03c3c44777d903840c608754a1be519f51e37bb6", nil),
+	// ? no public hash is known for listing a channel's moments; it must come from gql_overrides.json
+	CommunityMoments: newPersistedOperation("CommunityMoments", "", nil),
 }
 
 func newPersistedOperation(name, hash string, variables map[string]interface{}) GQLPersistedOperation {
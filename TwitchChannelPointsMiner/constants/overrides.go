@@ -0,0 +1,105 @@
+package constants
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+type GQLOverride struct {
+	OperationName string `json:"operationName"`
+	Sha256Hash    string `json:"sha256Hash"`
+	Version       int    `json:"version"`
+}
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ? overridableOperations maps both the Go field name and the Twitch operation name to the persisted operation.
+func overridableOperations() map[string]*GQLPersistedOperation {
+	ops := []*GQLPersistedOperation{
+		&GQLOperations.WithIsStreamLiveQuery,
+		&GQLOperations.PlaybackAccessToken,
+		&GQLOperations.VideoPlayerStreamInfoOverlay,
+		&GQLOperations.ClaimCommunityPoints,
+		&GQLOperations.CommunityMomentCalloutClaim,
+		&GQLOperations.DropsPageClaimDropRewards,
+		&GQLOperations.ChannelPointsContext,
+		&GQLOperations.JoinRaid,
+		&GQLOperations.ModViewChannelQuery,
+		&GQLOperations.Inventory,
+		&GQLOperations.MakePrediction,
+		&GQLOperations.ChannelPointsPredictionContext,
+		&GQLOperations.ViewerDropsDashboard,
+		&GQLOperations.DropCampaignDetails,
+		&GQLOperations.DropsHighlightServiceAvailable,
+		&GQLOperations.GetIDFromLogin,
+		&GQLOperations.ChannelFollows,
+		&GQLOperations.UserPointsContribution,
+		&GQLOperations.ContributeCommunityPointsCommunityGoal,
+		&GQLOperations.CommunityMoments,
+	}
+	byName := make(map[string]*GQLPersistedOperation, len(ops)*2)
+	for _, op := range ops {
+		byName[op.OperationName] = op
+	}
+	byName["VideoPlayerStreamInfoOverlay"] = &GQLOperations.VideoPlayerStreamInfoOverlay
+	byName["CommunityMomentCalloutClaim"] = &GQLOperations.CommunityMomentCalloutClaim
+	byName["DropsPageClaimDropRewards"] = &GQLOperations.DropsPageClaimDropRewards
+	byName["DropsHighlightServiceAvailable"] = &GQLOperations.DropsHighlightServiceAvailable
+	return byName
+}
+
+// ? ApplyGQLOverrides patches GQLOperations from a JSON file keyed by operation name.
+// ? A missing file is not an error; an invalid file is rejected as a whole so operations never end up half-patched.
+func ApplyGQLOverrides(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]GQLOverride
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	ops := overridableOperations()
+	for name, override := range overrides {
+		if _, ok := ops[name]; !ok {
+			return nil, fmt.Errorf("%s: unknown operation %q", path, name)
+		}
+		if override.Sha256Hash != "" && !sha256Pattern.MatchString(override.Sha256Hash) {
+			return nil, fmt.Errorf("%s: %s sha256Hash must be 64 lowercase hex characters", path, name)
+		}
+		if override.Version < 0 {
+			return nil, fmt.Errorf("%s: %s version must be positive", path, name)
+		}
+		if override.OperationName == "" && override.Sha256Hash == "" && override.Version == 0 {
+			return nil, fmt.Errorf("%s: %s overrides nothing", path, name)
+		}
+	}
+
+	applied := make([]string, 0, len(overrides))
+	for name, override := range overrides {
+		op := ops[name]
+		if override.OperationName != "" {
+			op.OperationName = override.OperationName
+		}
+		if override.Sha256Hash != "" {
+			op.Extensions.PersistedQuery.Sha256Hash = override.Sha256Hash
+		}
+		if override.Version > 0 {
+			op.Extensions.PersistedQuery.Version = override.Version
+		}
+		applied = append(applied, name)
+	}
+	sort.Strings(applied)
+	return applied, nil
+}
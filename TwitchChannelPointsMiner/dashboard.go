@@ -0,0 +1,133 @@
+package twitchchannelpointsminer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ? tuiLogLines is how many recent log lines the dashboard shows under the streamer table.
+const tuiLogLines = 12
+
+const dashboardRefresh = 2 * time.Second
+
+// ? logTail keeps the last console lines while the dashboard is active and passes writes straight through otherwise,
+// ? so login prompts before startup and the shutdown summary print normally.
+type logTail struct {
+	mu      sync.Mutex
+	out     io.Writer
+	lines   []string
+	max     int
+	holding bool
+}
+
+func newLogTail(out io.Writer, max int) *logTail {
+	return &logTail{out: out, max: max}
+}
+
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.holding {
+		return t.out.Write(p)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		t.lines = append(t.lines, line)
+	}
+	if extra := len(t.lines) - t.max; extra > 0 {
+		t.lines = append(t.lines[:0], t.lines[extra:]...)
+	}
+	return len(p), nil
+}
+
+func (t *logTail) hold() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.holding = true
+}
+
+func (t *logTail) active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.holding
+}
+
+func (t *logTail) snapshot() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+func (t *logTail) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.holding = false
+}
+
+// ? dashboard redraws a full-screen status view in place while tui_mode is on; shutdown releases the log tail to end it.
+func (m *Miner) dashboard(stop <-chan struct{}) {
+	if m.logger.tail == nil {
+		return
+	}
+	m.logger.tail.hold()
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	for m.logger.tail.active() {
+		m.renderDashboard(os.Stdout)
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *Miner) renderDashboard(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "Twitch Channel Points Miner | %s | up %s\n\n", m.Username, formatDuration(time.Since(m.startedAt)))
+
+	watching := make(map[string]struct{})
+	for _, name := range m.watchingNow() {
+		watching[name] = struct{}{}
+	}
+	fmt.Fprintf(&b, "%-24s %-8s %-6s %12s %10s\n", "STREAMER", "STATUS", "WATCH", "POINTS", "SESSION")
+	for _, s := range m.summaryOrder() {
+		status := fmt.Sprintf("%-8s", "offline")
+		if s.IsOnline {
+			status = colorGreen + fmt.Sprintf("%-8s", "live") + colorReset
+		}
+		watch := ""
+		if _, ok := watching[s.Username]; ok {
+			watch = "*"
+		}
+		gain := s.SessionProfit()
+		fmt.Fprintf(&b, "%-24s %s %-6s %12s %+10d\n", truncate(s.Username, 24), status, watch, formatChannelPoints(s.ChannelPoints), gain)
+	}
+
+	if m.pubsub != nil {
+		pending := m.pubsub.PendingBets()
+		fmt.Fprintf(&b, "\nPending bets: %d\n", len(pending))
+		for _, bet := range pending {
+			fmt.Fprintf(&b, "  %s: %s (in %s)\n", bet.Streamer, truncate(bet.Title, 60), bet.ClosesIn.Truncate(time.Second))
+		}
+	}
+
+	b.WriteString("\n")
+	for _, line := range m.logger.tail.snapshot() {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	_, _ = io.WriteString(w, b.String())
+}
+
+func truncate(s string, max int) string {
+	if len([]rune(s)) <= max {
+		return s
+	}
+	// ? ASCII ellipsis keeps fmt's byte-based padding aligned
+	return string([]rune(s)[:max-3]) + "..."
+}
@@ -0,0 +1,138 @@
+package twitchchannelpointsminer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	classpkg "TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+// ? followersRetryDelays are the waits between follow-list attempts at startup; Twitch hiccups rarely last longer.
+var followersRetryDelays = []time.Duration{5 * time.Second, 15 * time.Second, 45 * time.Second}
+
+type resolvedStreamers struct {
+	SavedAt    time.Time            `json:"saved_at"`
+	Streamers  []string             `json:"streamers"`
+	FollowedAt map[string]time.Time `json:"followed_at,omitempty"`
+}
+
+// ? followInfo is what FOLLOW_RECENT knows about a followed channel; recency 1 is the most recent follow.
+// ? It is copied to Streamer.FollowRecency, where 0 means the channel did not come from the follow list.
+type followInfo struct {
+	recency    int
+	followedAt time.Time
+}
+
+func resolvedStreamersPath(username string) string {
+	return filepath.Join("cookies", username+"_streamers_resolved.json")
+}
+
+// ? fetchFollowers loads the follow list with retries. After a success the list is saved for FollowersFallback;
+// ? once every attempt failed, the saved list is returned instead when FollowersFallback allows it.
+func (m *Miner) fetchFollowers(order entities.FollowersOrder) ([]classpkg.Follow, error) {
+	path := resolvedStreamersPath(m.Username)
+	var err error
+	for attempt := 0; ; attempt++ {
+		var follows []classpkg.Follow
+		follows, err = m.twitch.GetFollowers(100, order)
+		if err == nil {
+			saved := resolvedStreamers{SavedAt: time.Now(), Streamers: make([]string, 0, len(follows))}
+			for _, f := range follows {
+				saved.Streamers = append(saved.Streamers, f.Login)
+				if !f.FollowedAt.IsZero() {
+					if saved.FollowedAt == nil {
+						saved.FollowedAt = make(map[string]time.Time)
+					}
+					saved.FollowedAt[f.Login] = f.FollowedAt
+				}
+			}
+			raw, marshalErr := json.Marshal(saved)
+			if marshalErr == nil {
+				marshalErr = writeFileAtomic(path, raw)
+			}
+			if marshalErr != nil {
+				m.logger.Debugf("save follow list: %v", marshalErr)
+			}
+			return follows, nil
+		}
+		if attempt >= len(followersRetryDelays) {
+			break
+		}
+		delay := followersRetryDelays[attempt]
+		m.logger.Printf("failed to load followers (attempt %d/%d): %v; retrying in %s", attempt+1, len(followersRetryDelays)+1, err, delay)
+		time.Sleep(delay)
+	}
+	if !m.FollowersFallback {
+		return nil, err
+	}
+	raw, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, err
+	}
+	var saved resolvedStreamers
+	if jsonErr := json.Unmarshal(raw, &saved); jsonErr != nil || len(saved.Streamers) == 0 {
+		return nil, err
+	}
+	m.logger.Errorf("failed to load followers: %v; using the %d streamer(s) saved %s ago", err, len(saved.Streamers), formatDuration(time.Since(saved.SavedAt)))
+	follows := make([]classpkg.Follow, 0, len(saved.Streamers))
+	for _, login := range saved.Streamers {
+		follows = append(follows, classpkg.Follow{Login: login, FollowedAt: saved.FollowedAt[login]})
+	}
+	return follows, nil
+}
+
+// ? followRecency ranks follows from the most recent one. Follow dates are used when Twitch reported all of them;
+// ? otherwise the list order stands in for them, as it is sorted by follow date (oldest first for ASC).
+func followRecency(follows []classpkg.Follow, order entities.FollowersOrder) map[string]followInfo {
+	ranked := append([]classpkg.Follow(nil), follows...)
+	dated := true
+	for _, f := range ranked {
+		if f.FollowedAt.IsZero() {
+			dated = false
+			break
+		}
+	}
+	switch {
+	case dated:
+		sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].FollowedAt.After(ranked[j].FollowedAt) })
+	case order != entities.FollowersOrderDESC:
+		for i, j := 0, len(ranked)-1; i < j; i, j = i+1, j-1 {
+			ranked[i], ranked[j] = ranked[j], ranked[i]
+		}
+	}
+	info := make(map[string]followInfo, len(ranked))
+	for i, f := range ranked {
+		key := strings.ToLower(f.Login)
+		if _, ok := info[key]; !ok {
+			info[key] = followInfo{recency: i + 1, followedAt: f.FollowedAt}
+		}
+	}
+	return info
+}
+
+// ? resolveTargets returns the explicit streamers, followed by the follow list when useFollowers is set.
+// ? A follow list that cannot be loaded is only fatal when there is nothing else to mine.
+func (m *Miner) resolveTargets(streamers []string, useFollowers bool, order entities.FollowersOrder) []string {
+	if !useFollowers {
+		return streamers
+	}
+	follows, err := m.fetchFollowers(order)
+	if err != nil {
+		if len(streamers) == 0 {
+			m.logger.Fatalf("failed to load followers: %v", err)
+		}
+		m.logger.Errorf("failed to load followers: %v; mining the %d configured streamer(s) only", err, len(streamers))
+		return streamers
+	}
+	m.follows = followRecency(follows, order)
+	logins := make([]string, 0, len(follows))
+	for _, f := range follows {
+		logins = append(logins, f.Login)
+	}
+	return mergeTargets(streamers, logins)
+}
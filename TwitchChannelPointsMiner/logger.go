@@ -6,49 +6,144 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 type LoggerSettings struct {
-	Save             bool `json:"save"`
-	ConsoleLevel     int  `json:"console_level"`
-	FileLevel        int  `json:"file_level"`
-	Emoji            bool `json:"emoji"`
-	Smart            bool `json:"smart"`
-	ShowSeconds      bool `json:"show_seconds"`
-	ConsoleUsername  bool `json:"console_username"`
-	ShowClaimedBonus bool `json:"show_claimed_bonus_msg"`
-	Less             bool `json:"less"`
-	Debug            bool `json:"debug"`
+	Save              bool   `json:"save"`
+	ConsoleLevel      int    `json:"console_level"`
+	FileLevel         int    `json:"file_level"`
+	Emoji             bool   `json:"emoji"`
+	Smart             bool   `json:"smart"`
+	ShowSeconds       bool   `json:"show_seconds"`
+	ConsoleUsername   bool   `json:"console_username"`
+	ShowClaimedBonus  bool   `json:"show_claimed_bonus_msg"`
+	Less              bool   `json:"less"`
+	Debug             bool   `json:"debug"`
+	MaxLinesPerSecond int    `json:"max_lines_per_second"`
+	Output            string `json:"output"`
+	TUI               bool   `json:"tui"`
+	// ? SmartWindow is how long smart logging folds repeats of a line into one; 0 uses a minute.
+	SmartWindow time.Duration `json:"smart_window"`
+	// ? DropLogLevel is how claimed drops are logged: "full" (a line per drop), "summary" (a count per sweep) or "silent".
+	DropLogLevel string `json:"drop_log_level"`
+}
+
+type syslogWriter interface {
+	Info(m string) error
+	Err(m string) error
+	Debug(m string) error
 }
 
 type Logger struct {
 	base     *log.Logger
+	syslog   syslogWriter
 	settings LoggerSettings
+	tail     *logTail
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+	lastLine    string
+	repeated    int
+	dropped     int
+
+	smartKey    string
+	smartFirst  time.Time
+	smartLevel  string
+	smartLast   string
+	smartRepeat int
+	smartTimer  *time.Timer
 }
 
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// ? digitPattern lets smart logging treat lines that differ only in numbers (balances, counts, timings) as repeats.
+var digitPattern = regexp.MustCompile(`[0-9]+`)
+
+const defaultSmartWindow = time.Minute
+
 func NewLogger(settings LoggerSettings, username string) *Logger {
-	var output io.Writer = os.Stdout
-	if settings.Save {
-		logDir := "log"
-		if err := os.MkdirAll(logDir, 0o755); err == nil {
-			name := strings.TrimSpace(username)
-			if name == "" {
-				name = "miner"
-			}
-			name = sanitizeFilename(name)
-			logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", name))
-			f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-			if err == nil {
-				output = io.MultiWriter(os.Stdout, f)
-			}
+	logger := &Logger{settings: settings}
+	output := strings.ToLower(strings.TrimSpace(settings.Output))
+	var file io.Writer
+	if settings.Save || output == "file" {
+		file = openLogFile(username)
+	}
+	writers := make([]io.Writer, 0, 2)
+	console := func(w io.Writer) io.Writer {
+		if settings.ConsoleUsername && strings.TrimSpace(username) != "" {
+			return &prefixWriter{w: w, prefix: []byte("[" + strings.TrimSpace(username) + "] ")}
+		}
+		return w
+	}
+	switch output {
+	case "syslog":
+		w, err := newSyslogWriter("twitch-miner")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "syslog unavailable (%v), logging to stdout\n", err)
+			writers = append(writers, console(os.Stdout))
+		} else {
+			logger.syslog = w
 		}
+	case "file":
+		if file == nil {
+			fmt.Fprintln(os.Stderr, "log file unavailable, logging to stdout")
+			writers = append(writers, console(os.Stdout))
+		}
+	default:
+		if settings.TUI {
+			// ? the dashboard owns the screen; console lines are kept for it instead of scrolling past
+			logger.tail = newLogTail(os.Stdout, tuiLogLines)
+			writers = append(writers, console(logger.tail))
+		} else {
+			writers = append(writers, console(os.Stdout))
+		}
+	}
+	if file != nil {
+		writers = append(writers, file)
+	}
+	logger.base = log.New(io.MultiWriter(writers...), "", 0)
+	return logger
+}
+
+// ? prefixWriter puts the account name in front of console lines (show_username_in_console); log.Logger writes one line per call.
+// ? The log file is per account already, so it stays unprefixed.
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	line := make([]byte, 0, len(p.prefix)+len(b))
+	line = append(line, p.prefix...)
+	line = append(line, b...)
+	if _, err := p.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func openLogFile(username string) io.Writer {
+	logDir := "log"
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return nil
+	}
+	name := strings.TrimSpace(username)
+	if name == "" {
+		name = "miner"
 	}
-	return &Logger{
-		base:     log.New(output, "", 0),
-		settings: settings,
+	name = sanitizeFilename(name)
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", name))
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil
 	}
+	return f
 }
 
 func sanitizeFilename(name string) string {
@@ -67,6 +162,11 @@ func sanitizeFilename(name string) string {
 }
 
 func (l *Logger) log(level, emoji, format string, args ...interface{}) {
+	l.write(level, emoji, true, format, args...)
+}
+
+// ? write is log with smart logging optional; fold false keeps every line, for output that is read line by line.
+func (l *Logger) write(level, emoji string, fold bool, format string, args ...interface{}) {
 	if level == "DEBUG" && !l.settings.Debug {
 		return
 	}
@@ -74,14 +174,147 @@ func (l *Logger) log(level, emoji, format string, args ...interface{}) {
 	if emoji != "" && l.settings.Emoji {
 		message = fmt.Sprintf("%s %s", emojize(emoji), message)
 	}
-	timestampFormat := "15:04 02/01/06"
-	if l.settings.ShowSeconds {
-		timestampFormat = "15:04:05 02/01/06"
+	timestamp := l.timestamp()
+	if l.settings.Smart {
+		if fold && !l.dedup(level, message, timestamp) {
+			return
+		}
+		if !fold {
+			l.breakRepeats(timestamp)
+		}
+	}
+	if !l.allow(level, message, timestamp) {
+		return
+	}
+	if l.syslog != nil {
+		l.writeSyslog(level, message)
 	}
-	timestamp := time.Now().Format(timestampFormat)
 	l.base.Printf("[%s] %s: %s", level, timestamp, message)
 }
 
+// ? writeSyslog maps our levels onto syslog priorities; journald adds its own timestamp so only the message is sent.
+func (l *Logger) writeSyslog(level, message string) {
+	message = ansiPattern.ReplaceAllString(message, "")
+	switch level {
+	case "ERROR":
+		_ = l.syslog.Err(message)
+	case "DEBUG":
+		_ = l.syslog.Debug(message)
+	default:
+		_ = l.syslog.Info(message)
+	}
+}
+
+// ? allow applies MaxLinesPerSecond. Lines over the cap are counted and summarized before the next line that gets through.
+func (l *Logger) allow(level, message, timestamp string) bool {
+	if l.settings.MaxLinesPerSecond <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.windowStart) >= time.Second {
+		l.windowStart = now
+		l.windowCount = 0
+	}
+	line := level + "|" + message
+	if l.windowCount >= l.settings.MaxLinesPerSecond && level != "ERROR" {
+		if line == l.lastLine {
+			l.repeated++
+		} else {
+			l.dropped++
+		}
+		return false
+	}
+	if l.repeated > 0 {
+		l.base.Printf("[INFO] %s: last message repeated %d times", timestamp, l.repeated)
+		l.repeated = 0
+	}
+	if l.dropped > 0 {
+		l.base.Printf("[INFO] %s: %d line(s) dropped by log rate limit", timestamp, l.dropped)
+		l.dropped = 0
+	}
+	l.windowCount++
+	l.lastLine = line
+	return true
+}
+
+// ? dedup is smart logging: repeats of the previous line within SmartWindow, ignoring numbers, are held back and
+// ? written as one "(repeated Nx)" line with the latest values once a different line arrives or the window ends,
+// ? whichever comes first. Errors are never held back; they flush pending repeats first so the order stays intact.
+func (l *Logger) dedup(level, message, timestamp string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	window := l.settings.SmartWindow
+	if window <= 0 {
+		window = defaultSmartWindow
+	}
+	key := level + "|" + digitPattern.ReplaceAllString(ansiPattern.ReplaceAllString(message, ""), "#")
+	if level != "ERROR" && key == l.smartKey && now.Sub(l.smartFirst) < window {
+		l.smartLevel = level
+		l.smartLast = message
+		l.smartRepeat++
+		if l.smartTimer == nil {
+			l.smartTimer = time.AfterFunc(window-now.Sub(l.smartFirst), l.flushWindow)
+		}
+		return false
+	}
+	l.flushRepeatsLocked(timestamp)
+	l.smartKey = key
+	l.smartFirst = now
+	if level == "ERROR" {
+		l.smartKey = ""
+	}
+	return true
+}
+
+// ? flushWindow writes the repeats held back when the window ends without a different line arriving.
+func (l *Logger) flushWindow() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.smartTimer = nil
+	l.flushRepeatsLocked(l.timestamp())
+}
+
+func (l *Logger) flushRepeatsLocked(timestamp string) {
+	if l.smartTimer != nil {
+		l.smartTimer.Stop()
+		l.smartTimer = nil
+	}
+	if l.smartRepeat == 0 {
+		return
+	}
+	line := fmt.Sprintf("%s (repeated %dx)", l.smartLast, l.smartRepeat)
+	if l.syslog != nil {
+		l.writeSyslog(l.smartLevel, line)
+	}
+	l.base.Printf("[%s] %s: %s", l.smartLevel, timestamp, line)
+	l.smartRepeat = 0
+}
+
+// ? breakRepeats writes pending repeats before an unfolded line and ends their run, keeping the log in order.
+func (l *Logger) breakRepeats(timestamp string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushRepeatsLocked(timestamp)
+	l.smartKey = ""
+}
+
+// ? Flush writes repeats smart logging is still holding back; call it before exiting.
+func (l *Logger) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushRepeatsLocked(l.timestamp())
+}
+
+func (l *Logger) timestamp() string {
+	if l.settings.ShowSeconds {
+		return time.Now().Format("15:04:05 02/01/06")
+	}
+	return time.Now().Format("15:04 02/01/06")
+}
+
 func (l *Logger) Printf(format string, args ...interface{}) {
 	l.log("INFO", "", format, args...)
 }
@@ -90,12 +323,18 @@ func (l *Logger) Println(v ...interface{}) {
 	l.log("INFO", "", "%s", fmt.Sprint(v...))
 }
 
+// ? Auditf logs at INFO without smart logging folding it, so a series of similar lines stays complete.
+func (l *Logger) Auditf(format string, args ...interface{}) {
+	l.write("INFO", "", false, format, args...)
+}
+
 func (l *Logger) Errorf(format string, args ...interface{}) {
 	l.log("ERROR", "", format, args...)
 }
 
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.log("ERROR", "", format, args...)
+	l.Flush()
 	os.Exit(1)
 }
 
@@ -132,6 +371,49 @@ var emojiMap = map[string]string{
 	":performing_arts:":        "🎭",
 	":cry:":                    "😢",
 	":disappointed_relieved:":  "😥",
+	":warning:":                "⚠️",
+}
+
+func init() {
+	for code, val := range emojiMap {
+		emojiMap[code] = repairMojibake(val)
+	}
+}
+
+// ? cp1252Bytes maps the Windows-1252 characters in 0x80-0x9F back to their byte, since editors on Windows produce those.
+var cp1252Bytes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// ? repairMojibake undoes UTF-8 that was decoded as Latin-1/Windows-1252 and encoded again (e.g. "ðŸš€" for a rocket).
+// ? Values that are already proper UTF-8 are returned unchanged.
+func repairMojibake(s string) string {
+	raw := make([]byte, 0, len(s))
+	for _, r := range s {
+		if b, ok := cp1252Bytes[r]; ok {
+			raw = append(raw, b)
+			continue
+		}
+		if r > 0xFF {
+			return s
+		}
+		raw = append(raw, byte(r))
+	}
+	if len(raw) == utf8.RuneCount(raw) || !utf8.Valid(raw) {
+		return s
+	}
+	return string(raw)
+}
+
+// ? EmojiSelfTest logs one sample emoji at startup so users can tell whether their terminal renders them.
+func (l *Logger) EmojiSelfTest() {
+	if !l.settings.Emoji {
+		return
+	}
+	l.EmojiPrintf(":rocket:", "Emoji check: the line should start with a rocket; set \"emojis\" to false if it shows boxes or garbled text")
 }
 
 func emojize(code string) string {
@@ -0,0 +1,9 @@
+//go:build !windows && !plan9
+
+package twitchchannelpointsminer
+
+import "log/syslog"
+
+func newSyslogWriter(tag string) (syslogWriter, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+}
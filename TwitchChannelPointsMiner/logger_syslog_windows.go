@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package twitchchannelpointsminer
+
+import "errors"
+
+func newSyslogWriter(tag string) (syslogWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,55 @@
+package twitchchannelpointsminer
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSmartLoggingFlush(t *testing.T) {
+	tests := []struct {
+		name  string
+		after func(l *Logger)
+		want  []string
+	}{
+		{
+			"window ends without another line",
+			func(l *Logger) { time.Sleep(150 * time.Millisecond) },
+			[]string{"balance 1", "balance 3 (repeated 2x)"},
+		},
+		{
+			"unfolded line flushes first",
+			func(l *Logger) { l.Auditf("audit") },
+			[]string{"balance 1", "balance 3 (repeated 2x)", "audit"},
+		},
+		{
+			"different line flushes first",
+			func(l *Logger) { l.Printf("other") },
+			[]string{"balance 1", "balance 3 (repeated 2x)", "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := &Logger{base: log.New(&buf, "", 0), settings: LoggerSettings{Smart: true, SmartWindow: 50 * time.Millisecond}}
+			for i := 1; i <= 3; i++ {
+				l.Printf("balance %d", i)
+			}
+			tt.after(l)
+			l.mu.Lock()
+			out := buf.String()
+			l.mu.Unlock()
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tt.want), out)
+			}
+			for i, want := range tt.want {
+				if !strings.HasSuffix(lines[i], ": "+want) {
+					t.Errorf("line %d = %q, want suffix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package twitchchannelpointsminer
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"os/signal"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -32,10 +35,18 @@ const (
 	watchPrioritySubscribed
 	watchPriorityPointsAscending
 	watchPriorityPointsDescending
+	watchPriorityYield
+	watchPriorityFollowRecent
 )
 
 const maxConcurrentWatchers = 2
 
+// ? noStreamersRetry is how often on_no_streamers "wait" looks for streamers again.
+const noStreamersRetry = 10 * time.Minute
+
+// ? startupRetryDelay is the pause before the first startup retry pass; it doubles with each further pass.
+const startupRetryDelay = 15 * time.Second
+
 func defaultWatchPriorities() []watchPriority {
 	return []watchPriority{
 		watchPriorityStreak,
@@ -72,6 +83,10 @@ func parseWatchPriorities(priorityNames []string) []watchPriority {
 			add(watchPriorityPointsAscending)
 		case "POINTS_DESC", "POINTS_DESCENDING":
 			add(watchPriorityPointsDescending)
+		case "YIELD":
+			add(watchPriorityYield)
+		case "FOLLOW_RECENT":
+			add(watchPriorityFollowRecent)
 		}
 	}
 	if len(parsed) == 0 {
@@ -84,16 +99,48 @@ type Miner struct {
 	Username                   string
 	Password                   string
 	ClaimDropsStartup          bool
+	ClaimDropsStartupCampaigns []string
 	DisableSSLCertVerification bool
 	LoggerSettings             LoggerSettings
 	StreamerSettings           entities.StreamerSettings
+	StreamerOverrides          map[string]entities.StreamerSettings
+	TwitchSettings             classpkg.TwitchSettings
+	PubSubSettings             classpkg.PubSubSettings
+	BalanceSyncThreshold       int
+	NotifySettings             NotifySettings
+	TimerJitter                time.Duration
+	BalanceCSVPath             string
+	BalanceSampleInterval      time.Duration
+	SummarySort                string
+	SummaryJSONPath            string
+	DropsExpiryWarn            time.Duration
+	ExitOnAuthFailure          bool
+	MaxRuntime                 time.Duration
+	OnNoStreamers              string
+	StartupLoadRetries         int
+	LogWatchEvents             bool
+	RestartOnPanic             bool
+	StreakCatchupAfter         time.Duration
+	SessionBaseline            string
+	StreakMinPoints            int
+	PersistClaimedDrops        bool
+	WatchTimeReport            time.Duration
+	FollowersFallback          bool
 	logger                     *Logger
+	notifier                   *Notifier
+	expiryWarned               map[string]struct{}
+	claimedDrops               *claimedDropStore
 	startedAt                  time.Time
 	twitch                     *classpkg.Twitch
 	streamers                  []*entities.Streamer
-	initialPoints              map[string]int
+	lifetimeBaseline           map[string]pointsBaseline
+	lowViewers                 map[string]struct{}
+	follows                    map[string]followInfo
 	stop                       chan struct{}
 	watchPriorities            []watchPriority
+	pubsub                     *classpkg.PubSubClient
+	watchMu                    sync.Mutex
+	watching                   []string
 }
 
 func NewMiner(username, password string, claimDropsStartup bool, disableCertCheck bool, loggerSettings LoggerSettings, streamerSettings entities.StreamerSettings, priorityNames []string) *Miner {
@@ -120,16 +167,57 @@ func (m *Miner) MineFollowers(order entities.FollowersOrder) {
 	m.run(nil, true, order)
 }
 
-func (m *Miner) run(streamers []string, useFollowers bool, order entities.FollowersOrder) {
+// ? MineWithFollowers runs the miner on the explicit streamers first, followed by every followed channel not already listed.
+func (m *Miner) MineWithFollowers(streamers []string, order entities.FollowersOrder) {
+	m.run(streamers, true, order)
+}
+
+// ? ClaimAll claims every waiting bonus chest and inventory drop once, logs what was claimed and returns without mining.
+func (m *Miner) ClaimAll(streamers []string, useFollowers bool, order entities.FollowersOrder) {
 	m.startedAt = time.Now()
-	m.logger.Printf("Twitch Channel Points Miner | v%s", constants.Version)
-	m.logger.Println("https://github.com/0x8fv/Twitch-Channel-Points-Miner")
-	sessionID := newSessionID()
-	m.logger.EmojiPrintf(":green_circle:", "Start session: '%s'", sessionID)
-	m.stop = make(chan struct{})
-	m.initialPoints = make(map[string]int)
+	m.logger.Printf("Twitch Channel Points Miner | v%s | claim-all", constants.Version)
+	m.TwitchSettings.ClaimBonusOnLoad = true
+	m.login()
+	targets := m.resolveTargets(streamers, useFollowers, order)
 
-	tw, err := classpkg.NewTwitch(m.Username, utils.GetUserAgent("CHROME"), m.Password, m.logger)
+	bonuses, bonusPoints := 0, 0
+	m.logger.EmojiPrintf(":hourglass_flowing_sand:", "Checking %d streamer(s) for bonus chests...", len(targets))
+	for _, name := range targets {
+		if name == "" {
+			continue
+		}
+		settings := m.settingsFor(name)
+		// ? a cleanup run only collects points; contributing to goals would spend them
+		settings.CommunityGoals = false
+		s := &entities.Streamer{Username: name, Settings: settings}
+		id, err := m.twitch.GetChannelID(name)
+		if err != nil {
+			m.logger.Printf("skip %s: %v", name, err)
+			continue
+		}
+		s.ChannelID = id
+		bonus, err := m.twitch.LoadChannelPointsContext(s)
+		if err != nil {
+			m.logger.Printf("context for %s: %v", name, err)
+			continue
+		}
+		if bonus.Earned > 0 {
+			bonuses++
+			bonusPoints += bonus.Earned
+			m.logger.EmojiPrintf(":gift:", "Claimed bonus (%s+%d%s) → %s", colorGreen, bonus.Earned, colorReset, displayName(s))
+		}
+	}
+
+	drops, err := m.twitch.ClaimAllDropsFromInventory()
+	if err != nil {
+		m.logger.Errorf("drop claim failed: %v", err)
+	}
+	logged := m.logClaimedDrops(drops)
+	m.logger.EmojiPrintf(":moneybag:", "Claim-all done in %s: %d bonus chest(s) for %d points, %d drop(s)", formatDuration(time.Since(m.startedAt)), bonuses, bonusPoints, logged)
+}
+
+func (m *Miner) login() {
+	tw, err := classpkg.NewTwitch(m.Username, utils.GetUserAgent("CHROME"), m.Password, m.logger, m.TwitchSettings)
 	if err != nil {
 		m.logger.Fatalf("failed to create twitch client: %v", err)
 	}
@@ -137,19 +225,97 @@ func (m *Miner) run(streamers []string, useFollowers bool, order entities.Follow
 	if err := m.twitch.Login(m.Username); err != nil {
 		m.logger.Fatalf("login failed: %v", err)
 	}
-
-	var targets []string
-	if useFollowers {
-		follows, err := m.twitch.GetFollowers(100, order)
+	if m.PersistClaimedDrops {
+		store, err := loadClaimedDropStore(claimedDropsPath(m.Username))
 		if err != nil {
-			m.logger.Fatalf("failed to load followers: %v", err)
+			m.logger.Errorf("claimed drops file: %v", err)
 		}
-		targets = follows
-	} else {
-		targets = streamers
+		m.claimedDrops = store
 	}
+}
 
-	streamerObjs := make([]*entities.Streamer, 0, len(targets))
+func (m *Miner) run(streamers []string, useFollowers bool, order entities.FollowersOrder) {
+	m.startedAt = time.Now()
+	m.logger.Printf("Twitch Channel Points Miner | v%s", constants.Version)
+	m.logger.Println("https://github.com/0x8fv/Twitch-Channel-Points-Miner")
+	sessionID := newSessionID()
+	m.logger.EmojiPrintf(":green_circle:", "Start session: '%s'", sessionID)
+	m.logger.EmojiSelfTest()
+	m.stop = make(chan struct{})
+	m.lowViewers = make(map[string]struct{})
+	m.notifier = NewNotifier(m.NotifySettings, m.logger)
+	m.checkNotifyTargets()
+
+	m.login()
+	streamerObjs := m.loadStreamers(m.resolveTargets(streamers, useFollowers, order))
+	for len(streamerObjs) == 0 {
+		if !strings.EqualFold(m.OnNoStreamers, "wait") {
+			m.logger.Fatalf("No streamers loaded: the streamers list is empty, you follow no channels, or none of the names exist. Check streamers and mine_followers_too, or set on_no_streamers to \"wait\"")
+		}
+		m.logger.Printf("No streamers loaded; checking again in %s (on_no_streamers)", noStreamersRetry)
+		time.Sleep(noStreamersRetry)
+		streamerObjs = m.loadStreamers(m.resolveTargets(streamers, useFollowers, order))
+	}
+
+	m.loadLifetimeBaseline(streamerObjs)
+
+	if m.ClaimDropsStartup {
+		if drops, err := m.twitch.ClaimDropsFromInventory(m.ClaimDropsStartupCampaigns); err != nil {
+			m.logger.Printf("startup drop claim failed: %v", err)
+		} else {
+			m.logClaimedDrops(drops)
+		}
+	}
+
+	m.streamers = streamerObjs
+
+	// ? background loops
+	go m.supervise("drop claimer", func() { m.dropClaimer(m.stop) }, m.stop)
+	go m.supervise("moment poller", func() { m.momentPoller(streamerObjs, m.stop) }, m.stop)
+	go m.supervise("context refresher", func() { m.contextRefresher(streamerObjs, m.stop) }, m.stop)
+	go m.supervise("minute watcher", func() { m.minuteWatcher(streamerObjs, m.stop) }, m.stop)
+	m.pubsub = classpkg.NewPubSubClient(
+		m.twitch,
+		m.logger,
+		streamerObjs,
+		m.handlePubSubGain,
+		m.handlePubSubPresence,
+		m.notify,
+		m.PubSubSettings,
+	)
+	go m.supervise("PubSub", func() { m.pubsub.Start(m.stop) }, m.stop)
+	go m.dashboard(m.stop)
+	go m.balanceSampler(streamerObjs, m.stop)
+	go m.watchTimeReporter(streamerObjs, m.stop)
+	authExit := make(chan struct{})
+	go m.authWatchdog(authExit, m.stop)
+
+	var deadline <-chan time.Time
+	if m.MaxRuntime > 0 {
+		// ? counted from session start so slow streamer loading doesn't extend the session
+		stopAt := m.startedAt.Add(m.MaxRuntime)
+		timer := time.NewTimer(time.Until(stopAt))
+		defer timer.Stop()
+		deadline = timer.C
+		m.logger.EmojiPrintf(":alarm_clock:", "Session will stop at %s (max_runtime_minutes)", stopAt.Format("15:04 02/01/06"))
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-sigCh:
+	case <-authExit:
+	case <-deadline:
+		m.logger.Printf("Max runtime of %s reached", formatDuration(m.MaxRuntime))
+	}
+	m.shutdown(sessionID)
+}
+
+// ? loadStreamers resolves channel IDs, balances and presence for targets, skipping names that cannot be resolved.
+// ? Failures other than an unknown name are retried after the first pass, up to StartupLoadRetries times.
+func (m *Miner) loadStreamers(targets []string) []*entities.Streamer {
+	all := make([]*entities.Streamer, 0, len(targets))
+	var retry []*entities.Streamer
 	m.logger.EmojiPrintf(":hourglass_flowing_sand:", "Loading data for %d streamer(s). Please wait...", len(targets))
 	for _, name := range targets {
 		if name == "" {
@@ -157,71 +323,223 @@ func (m *Miner) run(streamers []string, useFollowers bool, order entities.Follow
 		}
 		s := &entities.Streamer{
 			Username:    name,
-			Settings:    m.StreamerSettings,
+			Settings:    m.settingsFor(name),
 			Stream:      entities.NewStream(),
 			StreamerURL: fmt.Sprintf("%s/%s", constants.URL, name),
 		}
-		id, err := m.twitch.GetChannelID(name)
-		if err != nil {
-			m.logger.Printf("skip %s: %v", name, err)
-			continue
+		if follow, ok := m.follows[strings.ToLower(name)]; ok {
+			s.FollowRecency = follow.recency
+			s.FollowedAt = follow.followedAt
 		}
-		s.ChannelID = id
-		prev := s.ChannelPoints
-		if _, err := m.twitch.LoadChannelPointsContext(s); err != nil {
-			m.logger.Printf("context for %s: %v", name, err)
+		all = append(all, s)
+		if m.loadStreamer(s) {
+			retry = append(retry, s)
+		}
+	}
+
+	delay := startupRetryDelay
+	for attempt := 1; len(retry) > 0 && attempt <= m.StartupLoadRetries; attempt++ {
+		names := make([]string, len(retry))
+		for i, s := range retry {
+			names[i] = s.Username
+		}
+		m.logger.Printf("Retrying %d streamer(s) in %s (attempt %d/%d): %s", len(retry), delay, attempt, m.StartupLoadRetries, strings.Join(names, ", "))
+		time.Sleep(delay)
+		delay *= 2
+		failed := retry[:0]
+		for _, s := range retry {
+			if m.loadStreamer(s) {
+				failed = append(failed, s)
+			}
+		}
+		retry = failed
+	}
+	for _, s := range retry {
+		if s.ChannelID == "" {
+			m.logger.Errorf("Could not load %s; it is skipped for this session", s.Username)
 		} else {
-			m.handlePointsUpdate(s, prev, "")
+			m.logger.Errorf("Balance of %s is still unknown; the 20-minute context refresh keeps trying", s.Username)
 		}
-		m.updatePresence(s)
-		streamerObjs = append(streamerObjs, s)
-		m.initialPoints[s.Username] = s.ChannelPoints
 	}
 
+	// ? kept in target order so the ORDER priority still follows the list
+	streamerObjs := make([]*entities.Streamer, 0, len(all))
+	for _, s := range all {
+		if s.ChannelID != "" {
+			streamerObjs = append(streamerObjs, s)
+		}
+	}
 	if len(streamerObjs) > 0 {
 		m.logger.EmojiPrintf(":white_check_mark:", "%d Streamer loaded!", len(streamerObjs))
 	}
+	return streamerObjs
+}
 
-	if m.ClaimDropsStartup {
-		if drops, err := m.twitch.ClaimAllDropsFromInventory(); err != nil {
-			m.logger.Printf("startup drop claim failed: %v", err)
-		} else {
-			m.logClaimedDrops(drops)
+// ? authWatchdog keeps a status line going while auth is halted and rechecks the token so mining resumes once it works.
+// ? With ExitOnAuthFailure it closes exit instead, ending the session through the normal shutdown path.
+func (m *Miner) authWatchdog(exit chan<- struct{}, stop <-chan struct{}) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !m.twitch.AuthHalted() {
+				continue
+			}
+			if m.ExitOnAuthFailure {
+				m.logger.Errorf("Stopping: %d consecutive auth failures (exit_on_auth_failure)", m.twitch.AuthFailures())
+				close(exit)
+				return
+			}
+			if m.twitch.RecheckAuth() {
+				m.logger.Printf("Auth token accepted again; resuming")
+				continue
+			}
+			m.logger.Printf("Paused: %d consecutive auth failures, still watching for a valid token", m.twitch.AuthFailures())
+		case <-m.twitch.ReloginRequests():
+			if err := m.twitch.Relogin(m.Username); err != nil {
+				m.logger.Errorf("re-login failed: %v", err)
+				continue
+			}
+			m.logger.Printf("Re-login done; the auth token is valid")
+		case <-stop:
+			return
 		}
 	}
+}
 
-	m.streamers = streamerObjs
+// ? mergeTargets concatenates the lists, dropping blanks and case-insensitive duplicates while keeping first occurrences.
+func mergeTargets(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	var merged []string
+	for _, list := range lists {
+		for _, name := range list {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if key == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, strings.TrimSpace(name))
+		}
+	}
+	return merged
+}
 
-	// ? background loops
-	go m.dropClaimer(m.stop)
-	go m.contextRefresher(streamerObjs, m.stop)
-	go m.minuteWatcher(streamerObjs, m.stop)
-	go m.startPubSub(streamerObjs, m.stop)
+// ? settingsFor returns the per-streamer override when one exists, otherwise the global settings.
+func (m *Miner) settingsFor(name string) entities.StreamerSettings {
+	if settings, ok := m.StreamerOverrides[strings.ToLower(name)]; ok {
+		return settings
+	}
+	return m.StreamerSettings
+}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	<-sigCh
-	m.shutdown(sessionID)
+// ? jittered shifts base by a random amount within ±TimerJitter so instances started together drift apart.
+func (m *Miner) jittered(base time.Duration) time.Duration {
+	if m.TimerJitter <= 0 {
+		return base
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(2*m.TimerJitter)+1))
+	if err != nil {
+		return base
+	}
+	d := base - m.TimerJitter + time.Duration(n.Int64())
+	if d < time.Minute {
+		d = time.Minute
+	}
+	return d
 }
 
 func (m *Miner) dropClaimer(stop <-chan struct{}) {
-	ticker := time.NewTicker(30 * time.Minute)
-	defer ticker.Stop()
+	m.warnExpiringDrops()
+	timer := time.NewTimer(m.jittered(30 * time.Minute))
+	defer timer.Stop()
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			if m.twitch.AuthHalted() {
+				timer.Reset(m.jittered(30 * time.Minute))
+				continue
+			}
 			if drops, err := m.twitch.ClaimAllDropsFromInventory(); err != nil {
 				m.logger.Printf("drop claim failed: %v", err)
 			} else {
 				m.logClaimedDrops(drops)
 			}
+			m.warnExpiringDrops()
+			timer.Reset(m.jittered(30 * time.Minute))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ? momentPoller claims moments PubSub missed, e.g. while reconnecting, on channels with claim_moments. It stops
+// ? right away when no channel claims moments or gql_overrides.json doesn't supply the CommunityMoments hash.
+func (m *Miner) momentPoller(streamers []*entities.Streamer, stop <-chan struct{}) {
+	enabled := false
+	for _, s := range streamers {
+		enabled = enabled || s.Settings.ClaimMoments
+	}
+	if !enabled {
+		return
+	}
+	timer := time.NewTimer(m.jittered(10 * time.Minute))
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if !m.twitch.AuthHalted() && !m.claimMissedMoments(streamers) {
+				return
+			}
+			timer.Reset(m.jittered(10 * time.Minute))
 		case <-stop:
 			return
 		}
 	}
 }
 
-func (m *Miner) logClaimedDrops(drops []classpkg.ClaimedDrop) {
+// ? claimMissedMoments claims the moments still open on each channel and returns false when polling is unavailable.
+func (m *Miner) claimMissedMoments(streamers []*entities.Streamer) bool {
+	for _, s := range streamers {
+		if !s.Settings.ClaimMoments {
+			continue
+		}
+		ids, err := m.twitch.ClaimableMoments(s)
+		if errors.Is(err, classpkg.ErrMomentsQueryUnset) {
+			m.logger.Printf("Moment polling disabled: set the CommunityMoments hash in gql_overrides.json to enable it")
+			return false
+		}
+		if err != nil {
+			m.logger.Debugf("moment poll %s: %v", s.Username, err)
+			continue
+		}
+		for _, id := range ids {
+			claimed, err := m.twitch.ClaimMomentOnce(s, id)
+			if err != nil {
+				m.logger.Errorf("claim moment %s: %v", s.Username, err)
+				continue
+			}
+			if claimed {
+				m.logger.EmojiPrintf(":video_camera:", "%s Claimed Moment", s.Username)
+			}
+		}
+	}
+	return true
+}
+
+// ? logClaimedDrops logs and notifies drops not reported before and returns how many that were.
+func (m *Miner) logClaimedDrops(drops []classpkg.ClaimedDrop) int {
+	claimed := len(drops)
+	drops, err := m.claimedDrops.fresh(drops)
+	if err != nil {
+		m.logger.Errorf("claimed drops file: %v", err)
+	}
+	if known := claimed - len(drops); known > 0 {
+		m.logger.Debugf("%d drop(s) were already claimed in an earlier session", known)
+	}
 	for _, drop := range drops {
 		reward := drop.RewardName
 		if reward == "" {
@@ -233,36 +551,101 @@ func (m *Miner) logClaimedDrops(drops []classpkg.ClaimedDrop) {
 		}
 		progress := formatDropProgress(drop.CurrentValue, drop.RequiredValue)
 		percent := progressPercent(drop.CurrentValue, drop.RequiredValue)
-		m.logger.EmojiPrintf(":package:", "Claim %s (%s) %s (%d%%)", reward, campaign, progress, percent)
+		if m.LoggerSettings.DropLogLevel == "full" || m.LoggerSettings.DropLogLevel == "" {
+			m.logger.EmojiPrintf(":package:", "Claim %s (%s) %s (%d%%)", reward, campaign, progress, percent)
+		} else {
+			m.logger.Debugf("Claim %s (%s) %s (%d%%)", reward, campaign, progress, percent)
+		}
+		m.notify(classpkg.Notification{Kind: classpkg.NotifyDropClaim, Reward: reward, Campaign: campaign})
+	}
+	if m.LoggerSettings.DropLogLevel == "summary" && len(drops) > 0 {
+		m.logger.EmojiPrintf(":package:", "Claimed %d drop(s)", len(drops))
+	}
+	return len(drops)
+}
+
+// ? warnExpiringDrops logs and notifies once per drop when its campaign ends within DropsExpiryWarn.
+func (m *Miner) warnExpiringDrops() {
+	if m.DropsExpiryWarn <= 0 {
+		return
+	}
+	drops, err := m.twitch.ExpiringDrops(m.DropsExpiryWarn)
+	if err != nil {
+		m.logger.Debugf("drop expiry check: %v", err)
+		return
+	}
+	if m.expiryWarned == nil {
+		m.expiryWarned = make(map[string]struct{})
+	}
+	for _, drop := range drops {
+		key := drop.CampaignName + "\x00" + drop.RewardName
+		if _, ok := m.expiryWarned[key]; ok {
+			continue
+		}
+		m.expiryWarned[key] = struct{}{}
+		progress := fmt.Sprintf("%s (%d%%)", formatDropProgress(drop.CurrentValue, drop.RequiredValue), progressPercent(drop.CurrentValue, drop.RequiredValue))
+		remaining := formatWatchTime(time.Until(drop.EndsAt))
+		m.logger.EmojiPrintf(":warning:", "%s (%s) at %s expires in %s", drop.RewardName, drop.CampaignName, progress, remaining)
+		m.notify(classpkg.Notification{
+			Kind:     classpkg.NotifyDropExpiry,
+			Reward:   drop.RewardName,
+			Campaign: drop.CampaignName,
+			Progress: progress,
+			Expires:  remaining,
+		})
 	}
 }
 
 func (m *Miner) contextRefresher(streamers []*entities.Streamer, stop <-chan struct{}) {
-	ticker := time.NewTicker(20 * time.Minute)
-	defer ticker.Stop()
+	timer := time.NewTimer(m.jittered(20 * time.Minute))
+	defer timer.Stop()
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			for _, s := range streamers {
+				if m.twitch.AuthHalted() {
+					break
+				}
 				prev := s.ChannelPoints
-				if _, err := m.twitch.LoadChannelPointsContext(s); err != nil {
+				if bonus, err := m.twitch.LoadChannelPointsContext(s); err != nil {
 					m.logger.Printf("refresh %s: %v", s.Username, err)
 				} else {
-					m.handlePointsUpdate(s, prev, "")
-					if s.Settings.ClaimDrops && s.Stream != nil {
+					m.handlePointsUpdate(s, prev, m.syncReason(s, prev))
+					m.creditBonusClaim(s, bonus)
+					if s.Settings.ClaimDrops {
 						if campaigns, err := m.twitch.CampaignIDsForStreamer(s); err == nil {
-							s.Stream.CampaignIDs = campaigns
+							s.SetCampaignIDs(campaigns)
 						}
 					}
 				}
 			}
+			timer.Reset(m.jittered(20 * time.Minute))
 		case <-stop:
 			return
 		}
 	}
 }
 
+func (m *Miner) setWatching(watchList []*entities.Streamer) {
+	names := make([]string, 0, len(watchList))
+	for _, s := range watchList {
+		names = append(names, s.Username)
+	}
+	m.watchMu.Lock()
+	m.watching = names
+	m.watchMu.Unlock()
+}
+
+// ? watchingNow returns the streamers currently holding a watch slot.
+func (m *Miner) watchingNow() []string {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	return append([]string(nil), m.watching...)
+}
+
 func (m *Miner) minuteWatcher(streamers []*entities.Streamer, stop <-chan struct{}) {
+	spadeWarned := make(map[string]struct{})
+	catchup := make(map[string]*entities.Streamer)
 	for {
 		select {
 		case <-stop:
@@ -271,7 +654,11 @@ func (m *Miner) minuteWatcher(streamers []*entities.Streamer, stop <-chan struct
 		}
 
 		watchList := m.pickStreamersToWatch(streamers)
-		if len(watchList) == 0 {
+		if urgent := m.streakCatchup(streamers, catchup, time.Now()); len(urgent) > 0 {
+			watchList = urgent
+		}
+		m.setWatching(watchList)
+		if len(watchList) == 0 || m.twitch.AuthHalted() {
 			if m.sleepWithStop(20*time.Second, stop) {
 				return
 			}
@@ -296,7 +683,18 @@ func (m *Miner) minuteWatcher(streamers []*entities.Streamer, stop <-chan struct
 			}
 
 			if err := m.twitch.SendMinuteWatched(streamer); err != nil {
-				m.logger.Printf("minute watch %s: %v", streamer.Username, err)
+				// ? a missing spade URL would fail every minute; report it once until the streamer recovers
+				if !errors.Is(err, classpkg.ErrSpadeUnavailable) {
+					m.logger.Printf("minute watch %s: %v", streamer.Username, err)
+				} else if _, warned := spadeWarned[streamer.Username]; !warned {
+					spadeWarned[streamer.Username] = struct{}{}
+					m.logger.Errorf("minute watch %s: %v (not repeated until it recovers)", streamer.Username, err)
+				}
+			} else {
+				delete(spadeWarned, streamer.Username)
+				if m.LogWatchEvents {
+					m.logWatchEvent(streamer)
+				}
 			}
 
 			if m.sleepWithStop(interval, stop) {
@@ -316,6 +714,9 @@ func (m *Miner) pickStreamersToWatch(streamers []*entities.Streamer) []*entities
 		if !s.OnlineAt.IsZero() && now.Sub(s.OnlineAt) < 30*time.Second {
 			continue
 		}
+		if m.belowMinViewers(s) {
+			continue
+		}
 		candidates = append(candidates, idx)
 	}
 
@@ -360,10 +761,10 @@ func (m *Miner) pickStreamersToWatch(streamers []*entities.Streamer) []*entities
 			drops := make([]int, 0, len(candidates))
 			for _, idx := range candidates {
 				s := streamers[idx]
-				if s == nil || s.Stream == nil {
+				if s == nil {
 					continue
 				}
-				if s.Settings.ClaimDrops && len(s.Stream.CampaignIDs) > 0 {
+				if s.Settings.ClaimDrops && s.CampaignCount() > 0 {
 					drops = append(drops, idx)
 				}
 			}
@@ -386,6 +787,23 @@ func (m *Miner) pickStreamersToWatch(streamers []*entities.Streamer) []*entities
 				return streamers[desc[i]].ChannelPoints > streamers[desc[j]].ChannelPoints
 			})
 			pick(desc)
+		case watchPriorityYield:
+			yield := append([]int(nil), candidates...)
+			sort.SliceStable(yield, func(i, j int) bool {
+				return streamers[yield[i]].PointsPerMinuteEstimate() > streamers[yield[j]].PointsPerMinuteEstimate()
+			})
+			pick(yield)
+		case watchPriorityFollowRecent:
+			recent := make([]int, 0, len(candidates))
+			for _, idx := range candidates {
+				if streamers[idx].FollowRecency > 0 {
+					recent = append(recent, idx)
+				}
+			}
+			sort.SliceStable(recent, func(i, j int) bool {
+				return streamers[recent[i]].FollowRecency < streamers[recent[j]].FollowRecency
+			})
+			pick(recent)
 		}
 	}
 
@@ -400,6 +818,26 @@ func (m *Miner) pickStreamersToWatch(streamers []*entities.Streamer) []*entities
 	return watchList
 }
 
+// ? belowMinViewers reports whether an online streamer has fewer viewers than its min_viewers_to_watch.
+// ? A count of 0 means it is not known yet, so the streamer stays eligible. Changes are logged at debug level once.
+func (m *Miner) belowMinViewers(s *entities.Streamer) bool {
+	below := false
+	if minimum := s.Settings.MinViewersToWatch; minimum > 0 && s.Stream != nil {
+		viewers := s.Stream.ViewersCount
+		below = viewers > 0 && viewers < minimum
+	}
+	_, skipped := m.lowViewers[s.Username]
+	switch {
+	case below && !skipped:
+		m.lowViewers[s.Username] = struct{}{}
+		m.logger.Debugf("Not watching %s: %d viewers < min_viewers_to_watch %d", s.Username, s.Stream.ViewersCount, s.Settings.MinViewersToWatch)
+	case !below && skipped:
+		delete(m.lowViewers, s.Username)
+		m.logger.Debugf("%s is eligible to watch again", s.Username)
+	}
+	return below
+}
+
 func (m *Miner) shouldPrioritizeStreak(streamer *entities.Streamer, now time.Time) bool {
 	if streamer == nil || streamer.Stream == nil {
 		return false
@@ -407,12 +845,50 @@ func (m *Miner) shouldPrioritizeStreak(streamer *entities.Streamer, now time.Tim
 	if !streamer.Settings.WatchStreak || !streamer.Stream.WatchStreakMissing {
 		return false
 	}
+	if streamer.ChannelPoints < m.StreakMinPoints {
+		return false
+	}
 	if !streamer.OfflineAt.IsZero() && now.Sub(streamer.OfflineAt) <= 30*time.Minute {
 		return false
 	}
 	return streamer.Stream.MinuteWatched < 7
 }
 
+// ? streakCatchup returns the streamers whose watch streak is at risk: still missing after StreakCatchupAfter online,
+// ? when the broadcast may end soon. They are watched exclusively so no minute-watched slot goes to anyone else.
+// ? engaged tracks who is in catch-up so entering and leaving it is logged once.
+func (m *Miner) streakCatchup(streamers []*entities.Streamer, engaged map[string]*entities.Streamer, now time.Time) []*entities.Streamer {
+	if m.StreakCatchupAfter <= 0 {
+		return nil
+	}
+	var urgent []*entities.Streamer
+	current := make(map[string]struct{})
+	for _, s := range streamers {
+		if len(urgent) >= maxConcurrentWatchers {
+			break
+		}
+		if s == nil || !s.IsOnline || s.OnlineAt.IsZero() || now.Sub(s.OnlineAt) < m.StreakCatchupAfter {
+			continue
+		}
+		if !m.shouldPrioritizeStreak(s, now) {
+			continue
+		}
+		urgent = append(urgent, s)
+		current[s.Username] = struct{}{}
+		if _, ok := engaged[s.Username]; !ok {
+			engaged[s.Username] = s
+			m.logger.EmojiPrintf(":warning:", "Streak catch-up for %s: online %s with %.0f/7 minutes watched, watching it exclusively", displayName(s), formatWatchTime(now.Sub(s.OnlineAt)), s.Stream.MinuteWatched)
+		}
+	}
+	for name, s := range engaged {
+		if _, ok := current[name]; !ok {
+			delete(engaged, name)
+			m.logger.Printf("Streak catch-up for %s ended", displayName(s))
+		}
+	}
+	return urgent
+}
+
 func (m *Miner) watchInterval(count int) time.Duration {
 	if count <= 0 {
 		return 20 * time.Second
@@ -451,35 +927,40 @@ func (m *Miner) sleepWithStop(duration time.Duration, stop <-chan struct{}) bool
 	return false
 }
 
-func (m *Miner) startPubSub(streamers []*entities.Streamer, stop <-chan struct{}) {
-	client := classpkg.NewPubSubClient(
-		m.twitch,
-		m.logger,
-		streamers,
-		m.handlePubSubGain,
-		m.handlePubSubPresence,
-	)
-	client.Start(stop)
-}
-
 func (m *Miner) shutdown(sessionID string) {
 	select {
 	case <-m.stop:
 	default:
 		close(m.stop)
 	}
+	if m.logger.tail != nil {
+		m.logger.tail.release()
+	}
 	fmt.Println()
 	fmt.Println()
 	fmt.Println()
 	m.logger.EmojiPrintf(":stop_sign:", "Ending session: '%s'", sessionID)
-	duration := formatDuration(time.Since(m.startedAt))
+	endedAt := time.Now()
+	duration := formatDuration(endedAt.Sub(m.startedAt))
 	m.logger.EmojiPrintf(":hourglass:", "Duration %s", duration)
-	for _, s := range m.streamers {
-		initial := m.initialPoints[s.Username]
-		total := s.ChannelPoints - initial
-		if total == 0 && (s.History == nil || len(s.History) == 0) {
+	summary := sessionSummary{
+		SessionID:       sessionID,
+		Username:        m.Username,
+		StartedAt:       m.startedAt,
+		EndedAt:         endedAt,
+		DurationSeconds: int64(endedAt.Sub(m.startedAt) / time.Second),
+		Baseline:        m.baselineLabel(),
+		Streamers:       []streamerSummary{},
+	}
+	if m.lifetimeBaselineEnabled() {
+		m.logger.Printf("Total Points are counted %s (session_baseline); history is for this session", summary.Baseline)
+	}
+	for _, s := range m.summaryOrder() {
+		total := s.ChannelPoints - m.summaryBaseline(s)
+		if total == 0 && len(s.History) == 0 && s.WatchedSession < time.Minute && s.RaidsJoined == 0 {
 			continue
 		}
+		summary.Streamers = append(summary.Streamers, newStreamerSummary(s, total))
 		signColor := colorGreen
 		sign := "+"
 		if total < 0 {
@@ -488,39 +969,172 @@ func (m *Miner) shutdown(sessionID string) {
 			total = -total
 		}
 		points := formatChannelPoints(s.ChannelPoints)
-		m.logger.EmojiPrintf(":moneybag:", "%s (%s%s%s points), Total Points %s%s%d%s", displayName(s.Username), colorCyan, points, colorReset, signColor, sign, total, colorReset)
-		if s.History != nil {
-			for reason, entry := range s.History {
-				m.logger.Printf("                         %s (%d times, %d gained)", reason, entry.Count, entry.Amount)
-			}
+		m.logger.EmojiPrintf(":moneybag:", "%s (%s%s%s points), Total Points %s%s%d%s", displayName(s), colorCyan, points, colorReset, signColor, sign, total, colorReset)
+		if s.WatchedSession >= time.Minute {
+			m.logger.Printf("                         Watched %s", formatWatchTime(s.WatchedSession))
+		}
+		if perAward, perHour, ok := s.WatchRate(); ok {
+			m.logger.Printf("                         Watch rate %.0f points per hour (%d awards, %.1f on average)", perHour, s.WatchAwards, perAward)
+		}
+		if s.RaidsJoined > 0 {
+			m.logger.Printf("                         Raids joined %d", s.RaidsJoined)
+		}
+		if roi, ok := s.PredictionROI(); ok {
+			m.logger.Printf("                         Predictions: %d wagered, %+d net, ROI %s", s.TotalWagered, s.PredictionNet, formatROI(roi))
+		}
+		reasons := make([]string, 0, len(s.History))
+		for reason := range s.History {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			entry := s.History[reason]
+			m.logger.Printf("                         %s (%d times, %d gained)", reason, entry.Count, entry.Amount)
 		}
 	}
+	m.logROISummary()
+	if m.SummaryJSONPath != "" {
+		if err := m.writeSummaryFile(summary); err != nil {
+			m.logger.Errorf("summary file: %v", err)
+		} else {
+			m.logger.Printf("Summary written to %s", m.SummaryJSONPath)
+		}
+	}
+	m.logger.Flush()
 	os.Exit(0)
 }
 
+// ? logROISummary reports realized prediction ROI overall and per strategy, counting settled bets only.
+func (m *Miner) logROISummary() {
+	wagered, net := 0, 0
+	byStrategy := make(map[string][2]int)
+	for _, s := range m.streamers {
+		if s.TotalWagered <= 0 {
+			continue
+		}
+		wagered += s.TotalWagered
+		net += s.PredictionNet
+		strategy := string(s.Settings.Bet.Strategy)
+		totals := byStrategy[strategy]
+		byStrategy[strategy] = [2]int{totals[0] + s.TotalWagered, totals[1] + s.PredictionNet}
+	}
+	if wagered == 0 {
+		return
+	}
+	m.logger.EmojiPrintf(":bar_chart:", "Predictions: %d wagered, %+d net, ROI %s", wagered, net, formatROI(float64(net)/float64(wagered)))
+	if len(byStrategy) < 2 {
+		return
+	}
+	strategies := make([]string, 0, len(byStrategy))
+	for strategy := range byStrategy {
+		strategies = append(strategies, strategy)
+	}
+	sort.Strings(strategies)
+	for _, strategy := range strategies {
+		totals := byStrategy[strategy]
+		m.logger.Printf("                         %s: %d wagered, %+d net, ROI %s", strategy, totals[0], totals[1], formatROI(float64(totals[1])/float64(totals[0])))
+	}
+}
+
+func formatROI(roi float64) string {
+	return fmt.Sprintf("%+.1f%%", roi*100)
+}
+
+// ? summaryOrder sorts the shutdown summary by SummarySort: "gain" (net gain, highest first), "name", or "order" (load order).
+func (m *Miner) summaryOrder() []*entities.Streamer {
+	ordered := append([]*entities.Streamer(nil), m.streamers...)
+	switch strings.ToLower(m.SummarySort) {
+	case "order":
+	case "name", "alphabetical":
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return strings.ToLower(ordered[i].Username) < strings.ToLower(ordered[j].Username)
+		})
+	default:
+		gain := func(s *entities.Streamer) int { return s.ChannelPoints - m.summaryBaseline(s) }
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return gain(ordered[i]) > gain(ordered[j])
+		})
+	}
+	return ordered
+}
+
 func (m *Miner) updatePresence(streamer *entities.Streamer) {
 	online, err := m.twitch.CheckStreamerOnline(streamer)
 	if err != nil {
 		m.logger.Printf("online check %s: %v", streamer.Username, err)
 		return
 	}
-	m.setPresence(streamer, online, "poll")
+	m.setPresence(streamer, online, "poll", time.Now())
 }
 
 func (m *Miner) logOnline(streamer *entities.Streamer) {
-	name := displayName(streamer.Username)
 	m.logger.EmojiPrintf(":speech_balloon:", "Join IRC Chat: %s", streamer.Username)
 	points := formatChannelPoints(streamer.ChannelPoints)
-	m.logger.EmojiPrintf(":partying_face:", "%s (%s%s%s points) is %sOnline%s!", name, colorCyan, points, colorReset, colorGreen, colorReset)
+	m.logger.EmojiPrintf(":partying_face:", "%s (%s%s%s points) is %sOnline%s!", displayName(streamer), colorCyan, points, colorReset, colorGreen, colorReset)
+	// ? notifications keep the login, since per-streamer notify settings are looked up by it
+	m.notify(classpkg.Notification{Kind: classpkg.NotifyOnline, Streamer: capitalize(streamer.Username), Points: streamer.ChannelPoints})
 }
 
 func (m *Miner) logOffline(streamer *entities.Streamer) {
-	name := displayName(streamer.Username)
 	points := formatChannelPoints(streamer.ChannelPoints)
-	m.logger.EmojiPrintf(":sleeping:", "%s (%s%s%s points) is %sOffline%s!", name, colorCyan, points, colorReset, colorRed, colorReset)
+	m.logger.EmojiPrintf(":sleeping:", "%s (%s%s%s points) is %sOffline%s!", displayName(streamer), colorCyan, points, colorReset, colorRed, colorReset)
+	m.notify(classpkg.Notification{Kind: classpkg.NotifyOffline, Streamer: capitalize(streamer.Username), Points: streamer.ChannelPoints})
+}
+
+func (m *Miner) notify(n classpkg.Notification) {
+	if n.Streamer != "" {
+		settings := m.settingsFor(n.Streamer)
+		if !notifyEventAllowed(settings.NotifyEvents, n.Kind) {
+			return
+		}
+		if n.Target == "" {
+			n.Target = settings.NotifyTarget
+		}
+	}
+	m.notifier.Notify(n)
+}
+
+// ? notifyEventAllowed applies a streamer's notify_events: nil allows every kind, an empty list none.
+func notifyEventAllowed(events []string, kind string) bool {
+	if events == nil {
+		return true
+	}
+	for _, event := range events {
+		if strings.EqualFold(strings.TrimSpace(event), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ? checkNotifyTargets reports streamer overrides that route to a target missing from notify.targets.
+func (m *Miner) checkNotifyTargets() {
+	names := make([]string, 0, len(m.StreamerOverrides))
+	for name := range m.StreamerOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if target := m.StreamerOverrides[name].NotifyTarget; target != "" && !m.notifier.HasTarget(target) {
+			m.logger.Errorf("notify: %s uses unknown notify_target %q, sending to the global destinations", name, target)
+		}
+		for _, event := range m.StreamerOverrides[name].NotifyEvents {
+			if _, ok := defaultNotifyTemplates[strings.ToLower(strings.TrimSpace(event))]; !ok {
+				m.logger.Errorf("notify: %s lists unknown notify_events entry %q", name, event)
+			}
+		}
+	}
+}
+
+// ? displayName is the channel name as Twitch shows it, falling back to the capitalized login until it is known.
+func displayName(s *entities.Streamer) string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return capitalize(s.Username)
 }
 
-func displayName(name string) string {
+func capitalize(name string) string {
 	if name == "" {
 		return ""
 	}
@@ -570,6 +1184,15 @@ func progressPercent(current, required int) int {
 	return percent
 }
 
+// ? formatWatchTime renders whole minutes as "2h 05m", or "45m" below an hour.
+func formatWatchTime(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
 func formatDuration(d time.Duration) string {
 	if d < 0 {
 		d = -d
@@ -598,20 +1221,62 @@ func formatDuration(d time.Duration) string {
 	return strings.Join(parts, " ")
 }
 
+// ? loadStreamer resolves the channel ID if needed and loads the balance. It reports whether a step failed in a way
+// ? worth retrying: an unknown name is final, a failed context load leaves the streamer in with an unknown balance.
+func (m *Miner) loadStreamer(s *entities.Streamer) (retry bool) {
+	firstLoad := s.ChannelID == ""
+	if firstLoad {
+		id, err := m.twitch.GetChannelID(s.Username)
+		if err != nil {
+			m.logger.Printf("skip %s: %v", s.Username, err)
+			return !errors.Is(err, classpkg.ErrUserNotFound)
+		}
+		s.ChannelID = id
+	}
+	bonus, err := m.twitch.LoadChannelPointsContext(s)
+	if err != nil {
+		m.logger.Printf("context for %s: %v", s.Username, err)
+		retry = true
+	} else {
+		s.StartSession(s.ChannelPoints)
+	}
+	if firstLoad {
+		m.updatePresence(s)
+	}
+	// ? credited after the baseline so a chest waiting at startup counts as session gain
+	m.creditBonusClaim(s, bonus)
+	return retry
+}
+
 func (m *Miner) handlePointsUpdate(streamer *entities.Streamer, previous int, reason string) {
 	if !streamer.PointsInit {
-		streamer.PointsInit = true
+		streamer.StartSession(streamer.ChannelPoints)
 		return
 	}
 	delta := streamer.ChannelPoints - previous
 	m.logPointsDelta(streamer, delta, reason)
 }
 
+// ? syncReason labels refresh deltas as SYNC once they reach BalanceSyncThreshold; smaller drift stays silent.
+func (m *Miner) syncReason(streamer *entities.Streamer, previous int) string {
+	if m.BalanceSyncThreshold <= 0 {
+		return ""
+	}
+	delta := streamer.ChannelPoints - previous
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta < m.BalanceSyncThreshold {
+		return ""
+	}
+	return "SYNC"
+}
+
 func (m *Miner) logPointsDelta(streamer *entities.Streamer, delta int, reason string) {
 	if delta == 0 {
 		return
 	}
-	name := displayName(streamer.Username)
+	name := displayName(streamer)
 	points := formatChannelPoints(streamer.ChannelPoints)
 	sign := "+"
 	valueColor := colorGreen
@@ -638,8 +1303,21 @@ func (m *Miner) logPointsDelta(streamer *entities.Streamer, delta int, reason st
 	)
 }
 
+// ? creditBonusClaim accounts for a chest claimed while loading the context, the same way as a PubSub claim.
+func (m *Miner) creditBonusClaim(streamer *entities.Streamer, bonus classpkg.BonusClaim) {
+	if bonus.Earned > 0 {
+		m.handlePubSubGain(streamer, bonus.Earned, entities.ReasonClaim, bonus.Balance)
+	}
+}
+
+// ? handlePubSubGain applies an award; balance is the reported post-award balance, or 0 when unknown.
 func (m *Miner) handlePubSubGain(streamer *entities.Streamer, earned int, reason string, balance int) {
 	prev := streamer.ChannelPoints
+	// ? a context-load claim and its PubSub echo report the same resulting balance; whichever comes second is already counted
+	if balance > 0 && earned > 0 && balance == prev && streamer.PointsInit {
+		m.logger.Debugf("%s +%d %s already counted (balance %d)", streamer.Username, earned, reason, balance)
+		return
+	}
 	expected := prev + earned
 	newBalance := balance
 	// ? Twitch may deliver PubSub gain events out of order; prefer monotonic increases
@@ -650,18 +1328,35 @@ func (m *Miner) handlePubSubGain(streamer *entities.Streamer, earned int, reason
 	if newBalance < prev {
 		newBalance = prev
 	}
-	streamer.ChannelPoints = newBalance
+	streamer.SetChannelPoints(newBalance)
 	if !streamer.PointsInit {
-		streamer.PointsInit = true
+		// ? the balance was unknown until now; only this award counts as gained
+		streamer.StartSession(newBalance - earned)
 	}
 	delta := earned
 	if delta == 0 {
 		delta = streamer.ChannelPoints - prev
 	}
-	m.logPointsDelta(streamer, delta, reason)
+	if reason == entities.ReasonWatch {
+		streamer.RecordWatchAward(earned, time.Now())
+	}
+	if reason == entities.ReasonClaim {
+		m.logClaimedBonus(streamer, delta)
+	} else {
+		m.logPointsDelta(streamer, delta, reason)
+	}
 	m.updateHistory(streamer, reason, earned)
 }
 
+// ? logClaimedBonus reports bonus chest claims, honoring show_claimed_bonus_msg.
+func (m *Miner) logClaimedBonus(streamer *entities.Streamer, amount int) {
+	if !m.LoggerSettings.ShowClaimedBonus || amount <= 0 {
+		return
+	}
+	points := formatChannelPoints(streamer.ChannelPoints)
+	m.logger.EmojiPrintf(":gift:", "Claimed bonus (%s+%d%s) → %s (%s%s%s points)", colorGreen, amount, colorReset, displayName(streamer), colorCyan, points, colorReset)
+}
+
 func (m *Miner) updateHistory(streamer *entities.Streamer, reason string, amount int) {
 	if reason == "" {
 		return
@@ -676,27 +1371,28 @@ func (m *Miner) updateHistory(streamer *entities.Streamer, reason string, amount
 	}
 	entry.Count++
 	entry.Amount += amount
-	if reason == "WATCH_STREAK" && streamer.Stream != nil {
+	if reason == entities.ReasonWatchStreak && streamer.Stream != nil {
 		streamer.Stream.WatchStreakMissing = false
 	}
 }
 
-func (m *Miner) handlePubSubPresence(streamer *entities.Streamer, online bool, reason string) {
-	m.setPresence(streamer, online, fmt.Sprintf("pubsub:%s", reason))
+func (m *Miner) handlePubSubPresence(streamer *entities.Streamer, online bool, reason string, at time.Time) {
+	m.setPresence(streamer, online, fmt.Sprintf("pubsub:%s", reason), at)
 }
 
-func (m *Miner) setPresence(streamer *entities.Streamer, online bool, reason string) {
+// ? setPresence records a presence change; at is when it happened, the PubSub server time when available.
+func (m *Miner) setPresence(streamer *entities.Streamer, online bool, reason string, at time.Time) {
 	prevKnown := streamer.PresenceKnown
 	prevOnline := streamer.IsOnline
 	streamer.PresenceKnown = true
 	if online != prevOnline || !prevKnown {
 		if online {
-			streamer.OnlineAt = time.Now()
+			streamer.OnlineAt = at
 		} else {
-			streamer.OfflineAt = time.Now()
+			streamer.OfflineAt = at
 		}
 	}
-	streamer.IsOnline = online
+	streamer.SetOnline(online)
 	if !prevKnown {
 		if online {
 			m.logOnline(streamer)
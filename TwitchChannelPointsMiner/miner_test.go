@@ -0,0 +1,58 @@
+package twitchchannelpointsminer
+
+import (
+	"testing"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestFirstPointsUpdateAfterRestart(t *testing.T) {
+	tests := []struct {
+		name        string
+		known       bool // PointsInit: the balance was loaded before the update
+		balance     int
+		earned      int
+		reported    int // post-award balance from PubSub, 0 when the update is a refresh
+		wantStart   int
+		wantBalance int
+		wantHistory int
+	}{
+		{"restart then gain", false, 0, 50, 1050, 1000, 1050, 50},
+		{"restart then refresh", false, 1000, 0, 0, 1000, 1000, 0},
+		{"loaded then gain", true, 1000, 50, 1050, 1000, 1050, 50},
+		{"loaded then gain without balance", true, 1000, 50, 0, 1000, 1050, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Miner{logger: NewLogger(LoggerSettings{}, "test")}
+			s := &entities.Streamer{Username: "streamer", ChannelPoints: tt.balance}
+			if tt.known {
+				s.StartSession(tt.balance)
+			}
+			if tt.earned > 0 {
+				m.handlePubSubGain(s, tt.earned, entities.ReasonWatch, tt.reported)
+			} else {
+				m.handlePointsUpdate(s, 0, "")
+			}
+			if !s.PointsInit {
+				t.Fatal("PointsInit not set")
+			}
+			if s.SessionStart != tt.wantStart {
+				t.Errorf("SessionStart = %d, want %d", s.SessionStart, tt.wantStart)
+			}
+			if s.ChannelPoints != tt.wantBalance {
+				t.Errorf("ChannelPoints = %d, want %d", s.ChannelPoints, tt.wantBalance)
+			}
+			got := 0
+			if entry := s.History[entities.ReasonWatch]; entry != nil {
+				got = entry.Amount
+			}
+			if got != tt.wantHistory {
+				t.Errorf("WATCH history = %d, want %d", got, tt.wantHistory)
+			}
+			if profit := s.SessionProfit(); profit != tt.wantBalance-tt.wantStart {
+				t.Errorf("SessionProfit = %d, want %d", profit, tt.wantBalance-tt.wantStart)
+			}
+		})
+	}
+}
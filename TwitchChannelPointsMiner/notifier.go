@@ -0,0 +1,228 @@
+package twitchchannelpointsminer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	classpkg "TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes"
+)
+
+type NotifySettings struct {
+	WebhookURL        string
+	DiscordWebhookURL string
+	TelegramToken     string
+	TelegramChatID    string
+	Templates         map[string]string
+	Targets           map[string]NotifyTarget
+}
+
+// ? NotifyTarget is a named set of destinations; empty fields inherit the global ones, so a route can reuse the bot token.
+type NotifyTarget struct {
+	WebhookURL        string
+	DiscordWebhookURL string
+	TelegramToken     string
+	TelegramChatID    string
+}
+
+func (t NotifyTarget) enabled() bool {
+	return t.WebhookURL != "" || t.DiscordWebhookURL != "" || (t.TelegramToken != "" && t.TelegramChatID != "")
+}
+
+var defaultNotifyTemplates = map[string]string{
+	classpkg.NotifyOnline:           "{{.Streamer}} ({{points .Points}} points) is Online!",
+	classpkg.NotifyOffline:          "{{.Streamer}} ({{points .Points}} points) is Offline!",
+	classpkg.NotifyBet:              "Place {{points .Amount}} points on: {{.Outcome}} for {{.Streamer}}",
+	classpkg.NotifyPredictionResult: "{{.Streamer}} - {{.Title}} - Decision: {{.Outcome}} - Result: {{.Result}}",
+	classpkg.NotifyDropClaim:        "Claim {{.Reward}} ({{.Campaign}})",
+	classpkg.NotifyDropExpiry:       "{{.Reward}} ({{.Campaign}}) at {{.Progress}} expires in {{.Expires}}",
+}
+
+var notifyTemplateFuncs = template.FuncMap{
+	"points": formatChannelPoints,
+}
+
+type Notifier struct {
+	settings  NotifySettings
+	templates map[string]*template.Template
+	client    *http.Client
+	logger    *Logger
+}
+
+// ? NewNotifier validates every custom template up front; a broken one is reported and replaced by its default.
+func NewNotifier(settings NotifySettings, logger *Logger) *Notifier {
+	n := &Notifier{
+		settings:  settings,
+		templates: make(map[string]*template.Template, len(defaultNotifyTemplates)),
+		client:    newHTTPClient(10 * time.Second),
+		logger:    logger,
+	}
+	for kind, text := range defaultNotifyTemplates {
+		n.templates[kind] = template.Must(parseNotifyTemplate(kind, text))
+	}
+
+	kinds := make([]string, 0, len(settings.Templates))
+	for kind := range settings.Templates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if _, ok := defaultNotifyTemplates[kind]; !ok {
+			logger.Printf("notify: unknown template %q ignored (known: %s)", kind, strings.Join(notifyKinds(), ", "))
+			continue
+		}
+		tmpl, err := parseNotifyTemplate(kind, settings.Templates[kind])
+		if err != nil {
+			logger.Errorf("notify: template %q is invalid, using default: %v", kind, err)
+			continue
+		}
+		n.templates[kind] = tmpl
+	}
+	return n
+}
+
+// ? parseNotifyTemplate also executes the template once so references to unknown fields fail at startup, not mid-session.
+func parseNotifyTemplate(kind, text string) (*template.Template, error) {
+	tmpl, err := template.New(kind).Funcs(notifyTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, classpkg.Notification{Kind: kind}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func notifyKinds() []string {
+	kinds := make([]string, 0, len(defaultNotifyTemplates))
+	for kind := range defaultNotifyTemplates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (n *Notifier) Enabled() bool {
+	if n == nil {
+		return false
+	}
+	if n.global().enabled() {
+		return true
+	}
+	for name := range n.settings.Targets {
+		if n.route(name).enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) global() NotifyTarget {
+	s := n.settings
+	return NotifyTarget{
+		WebhookURL:        s.WebhookURL,
+		DiscordWebhookURL: s.DiscordWebhookURL,
+		TelegramToken:     s.TelegramToken,
+		TelegramChatID:    s.TelegramChatID,
+	}
+}
+
+// ? route resolves a target name to its destinations; unknown or empty names fall back to the global ones.
+func (n *Notifier) route(name string) NotifyTarget {
+	dest := n.global()
+	target, ok := n.settings.Targets[name]
+	if name == "" || !ok {
+		return dest
+	}
+	if target.WebhookURL != "" {
+		dest.WebhookURL = target.WebhookURL
+	}
+	if target.DiscordWebhookURL != "" {
+		dest.DiscordWebhookURL = target.DiscordWebhookURL
+	}
+	if target.TelegramToken != "" {
+		dest.TelegramToken = target.TelegramToken
+	}
+	if target.TelegramChatID != "" {
+		dest.TelegramChatID = target.TelegramChatID
+	}
+	return dest
+}
+
+// ? HasTarget reports whether name is defined under notify.targets.
+func (n *Notifier) HasTarget(name string) bool {
+	if n == nil {
+		return false
+	}
+	_, ok := n.settings.Targets[name]
+	return ok
+}
+
+// ? Notify renders the event and delivers it in the background so a slow endpoint never stalls mining.
+func (n *Notifier) Notify(event classpkg.Notification) {
+	if !n.Enabled() {
+		return
+	}
+	tmpl, ok := n.templates[event.Kind]
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		n.logger.Errorf("notify: render %s: %v", event.Kind, err)
+		return
+	}
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return
+	}
+	go n.deliver(n.route(event.Target), event.Kind, message)
+}
+
+func (n *Notifier) deliver(dest NotifyTarget, kind, message string) {
+	if dest.WebhookURL != "" {
+		n.postJSON("webhook", dest.WebhookURL, map[string]string{"event": kind, "message": message})
+	}
+	if dest.DiscordWebhookURL != "" {
+		n.postJSON("discord", dest.DiscordWebhookURL, map[string]string{"content": message})
+	}
+	if dest.TelegramToken != "" && dest.TelegramChatID != "" {
+		endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", dest.TelegramToken)
+		form := url.Values{"chat_id": {dest.TelegramChatID}, "text": {message}}
+		resp, err := n.client.PostForm(endpoint, form)
+		n.checkResponse("telegram", resp, err)
+	}
+}
+
+func (n *Notifier) postJSON(target, endpoint string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Errorf("notify %s: %v", target, err)
+		return
+	}
+	resp, err := n.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	n.checkResponse(target, resp, err)
+}
+
+func (n *Notifier) checkResponse(target string, resp *http.Response, err error) {
+	if err != nil {
+		// ? url.Error would echo the Telegram bot token back into the log
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		n.logger.Errorf("notify %s: %v", target, err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		n.logger.Errorf("notify %s: unexpected status %s", target, resp.Status)
+	}
+}
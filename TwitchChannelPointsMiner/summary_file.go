@@ -0,0 +1,121 @@
+package twitchchannelpointsminer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+type sessionSummary struct {
+	SessionID       string            `json:"session_id"`
+	Username        string            `json:"username"`
+	StartedAt       time.Time         `json:"started_at"`
+	EndedAt         time.Time         `json:"ended_at"`
+	DurationSeconds int64             `json:"duration_seconds"`
+	Baseline        string            `json:"baseline"`
+	TotalGained     int               `json:"total_gained"`
+	Predictions     *predictionStats  `json:"predictions,omitempty"`
+	Streamers       []streamerSummary `json:"streamers"`
+}
+
+type streamerSummary struct {
+	Username       string                    `json:"username"`
+	DisplayName    string                    `json:"display_name"`
+	ChannelPoints  int                       `json:"channel_points"`
+	Gained         int                       `json:"gained"`
+	WatchedMinutes int                       `json:"watched_minutes"`
+	RaidsJoined    int                       `json:"raids_joined"`
+	Predictions    *predictionStats          `json:"predictions,omitempty"`
+	WatchRate      *watchRate                `json:"watch_rate,omitempty"`
+	History        map[string]historySummary `json:"history"`
+}
+
+type historySummary struct {
+	Count  int `json:"count"`
+	Amount int `json:"amount"`
+}
+
+type watchRate struct {
+	Awards         int     `json:"awards"`
+	Points         int     `json:"points"`
+	PointsPerAward float64 `json:"points_per_award"`
+	PointsPerHour  float64 `json:"points_per_hour"`
+}
+
+type predictionStats struct {
+	Wagered int     `json:"wagered"`
+	Net     int     `json:"net"`
+	ROI     float64 `json:"roi"`
+}
+
+func newStreamerSummary(s *entities.Streamer, gained int) streamerSummary {
+	entry := streamerSummary{
+		Username:       s.Username,
+		DisplayName:    displayName(s),
+		ChannelPoints:  s.ChannelPoints,
+		Gained:         gained,
+		WatchedMinutes: int(s.WatchedSession / time.Minute),
+		RaidsJoined:    s.RaidsJoined,
+		History:        make(map[string]historySummary, len(s.History)),
+	}
+	if roi, ok := s.PredictionROI(); ok {
+		entry.Predictions = &predictionStats{Wagered: s.TotalWagered, Net: s.PredictionNet, ROI: roi}
+	}
+	if perAward, perHour, ok := s.WatchRate(); ok {
+		entry.WatchRate = &watchRate{Awards: s.WatchAwards, Points: s.WatchAwardPoints, PointsPerAward: perAward, PointsPerHour: perHour}
+	}
+	for reason, h := range s.History {
+		entry.History[reason] = historySummary{Count: h.Count, Amount: h.Amount}
+	}
+	return entry
+}
+
+// ? writeSummaryFile stores the shutdown summary as JSON at SummaryJSONPath.
+func (m *Miner) writeSummaryFile(summary sessionSummary) error {
+	for _, s := range summary.Streamers {
+		summary.TotalGained += s.Gained
+		if s.Predictions == nil {
+			continue
+		}
+		if summary.Predictions == nil {
+			summary.Predictions = &predictionStats{}
+		}
+		summary.Predictions.Wagered += s.Predictions.Wagered
+		summary.Predictions.Net += s.Predictions.Net
+	}
+	if summary.Predictions != nil && summary.Predictions.Wagered > 0 {
+		summary.Predictions.ROI = float64(summary.Predictions.Net) / float64(summary.Predictions.Wagered)
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.SummaryJSONPath, data)
+}
+
+// ? writeFileAtomic writes a temp file next to path and renames it over path, so a concurrent reader sees
+// ? either the previous content or the complete new one.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
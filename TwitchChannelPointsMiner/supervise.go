@@ -0,0 +1,38 @@
+package twitchchannelpointsminer
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// ? panicRestartDelay is the pause before a crashed background loop is started again.
+var panicRestartDelay = 5 * time.Second
+
+// ? supervise runs a background loop; if it panics the panic is logged with its stack and, with RestartOnPanic,
+// ? the loop is started again. A loop that returns on its own is not restarted.
+func (m *Miner) supervise(name string, loop func(), stop <-chan struct{}) {
+	for {
+		if !m.runRecovered(name, loop) {
+			return
+		}
+		if !m.RestartOnPanic {
+			m.logger.Errorf("%s stopped after a panic and will not be restarted (restart_on_panic is off)", name)
+			return
+		}
+		m.logger.Printf("Restarting %s in %s", name, panicRestartDelay)
+		if m.sleepWithStop(panicRestartDelay, stop) {
+			return
+		}
+	}
+}
+
+func (m *Miner) runRecovered(name string, loop func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			m.logger.Errorf("%s panicked: %v\n%s", name, r, debug.Stack())
+		}
+	}()
+	loop()
+	return false
+}
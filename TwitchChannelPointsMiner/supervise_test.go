@@ -0,0 +1,42 @@
+package twitchchannelpointsminer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSupervise(t *testing.T) {
+	defer func(d time.Duration) { panicRestartDelay = d }(panicRestartDelay)
+	panicRestartDelay = time.Millisecond
+	tests := []struct {
+		name      string
+		restart   bool
+		panics    int
+		stopped   bool
+		wantCalls int
+	}{
+		{"restarts until the loop returns", true, 2, false, 3},
+		{"no restart when disabled", false, 2, false, 1},
+		{"no restart after stop", true, 2, true, 1},
+		{"loop returning is not restarted", true, 0, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Miner{logger: NewLogger(LoggerSettings{}, "test"), RestartOnPanic: tt.restart}
+			stop := make(chan struct{})
+			if tt.stopped {
+				close(stop)
+			}
+			calls := 0
+			m.supervise("loop", func() {
+				calls++
+				if calls <= tt.panics {
+					panic("boom")
+				}
+			}, stop)
+			if calls != tt.wantCalls {
+				t.Errorf("loop ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
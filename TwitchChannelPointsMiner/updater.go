@@ -1,7 +1,6 @@
 package twitchchannelpointsminer
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,10 +16,17 @@ import (
 	"time"
 
 	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/constants"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/utils"
 )
 
 const releasesURL = "https://api.github.com/repos/0x8fv/Twitch-Channel-Points-Miner/releases/latest"
 
+// ? relaunchEnv carries the unix time of an updater relaunch into the new process.
+const relaunchEnv = "TWITCH_MINER_UPDATED_AT"
+
+// ? updateLoopGuard is how long after an updater relaunch further updates are refused, breaking install/restart loops.
+const updateLoopGuard = 10 * time.Minute
+
 type releaseAsset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
@@ -31,7 +37,12 @@ type githubRelease struct {
 	Assets  []releaseAsset `json:"assets"`
 }
 
-func RunAutoUpdate(disableSSL bool) (bool, error) {
+func RunAutoUpdate() (bool, error) {
+	if since, ok := sinceUpdaterRelaunch(time.Now()); ok && since < updateLoopGuard {
+		log.Printf("auto-update: skipped, this process was started by the updater %s ago", since.Round(time.Second))
+		return false, nil
+	}
+
 	exePath, err := os.Executable()
 	if err != nil {
 		return false, fmt.Errorf("locate executable: %w", err)
@@ -39,7 +50,7 @@ func RunAutoUpdate(disableSSL bool) (bool, error) {
 
 	devRun := isGoRunExecutable(exePath)
 
-	release, err := fetchLatestRelease(disableSSL)
+	release, err := fetchLatestRelease()
 	if err != nil {
 		return false, err
 	}
@@ -61,7 +72,7 @@ func RunAutoUpdate(disableSSL bool) (bool, error) {
 	}
 
 	log.Printf("auto-update: found newer version %s (asset %s)", release.TagName, asset.Name)
-	tempPath, err := downloadAsset(asset.BrowserDownloadURL, filepath.Dir(exePath), disableSSL)
+	tempPath, err := downloadAsset(asset.BrowserDownloadURL, filepath.Dir(exePath))
 	if err != nil {
 		return false, fmt.Errorf("download update: %w", err)
 	}
@@ -79,7 +90,7 @@ func RunAutoUpdate(disableSSL bool) (bool, error) {
 	return true, nil
 }
 
-func fetchLatestRelease(disableSSL bool) (githubRelease, error) {
+func fetchLatestRelease() (githubRelease, error) {
 	req, err := http.NewRequest(http.MethodGet, releasesURL, nil)
 	if err != nil {
 		return githubRelease{}, err
@@ -87,7 +98,7 @@ func fetchLatestRelease(disableSSL bool) (githubRelease, error) {
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("User-Agent", "TwitchChannelPointsMiner-Updater")
 
-	client := newHTTPClient(disableSSL, 15*time.Second)
+	client := newHTTPClient(15 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return githubRelease{}, fmt.Errorf("fetch release: %w", err)
@@ -118,14 +129,14 @@ func pickAsset(assets []releaseAsset, goos, arch string) (releaseAsset, error) {
 	return releaseAsset{}, fmt.Errorf("no release asset for %s/%s", goos, arch)
 }
 
-func downloadAsset(url, dir string, disableSSL bool) (string, error) {
+func downloadAsset(url, dir string) (string, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("User-Agent", "TwitchChannelPointsMiner-Updater")
 
-	client := newHTTPClient(disableSSL, 5*time.Minute)
+	client := newHTTPClient(5 * time.Minute)
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -170,8 +181,26 @@ func replaceExecutable(targetPath, newPath string) error {
 	return nil
 }
 
+// ? sinceUpdaterRelaunch reports how long ago the updater relaunched this process, if it did.
+func sinceUpdaterRelaunch(now time.Time) (time.Duration, bool) {
+	raw := os.Getenv(relaunchEnv)
+	if raw == "" {
+		return 0, false
+	}
+	stamp, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(time.Unix(stamp, 0)), true
+}
+
+func relaunchMarker() string {
+	return fmt.Sprintf("%s=%d", relaunchEnv, time.Now().Unix())
+}
+
 func relaunch(targetPath string, args []string) error {
 	cmd := exec.Command(targetPath, args...)
+	cmd.Env = append(os.Environ(), relaunchMarker())
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -186,6 +215,7 @@ setlocal
 set "TARGET=%s"
 set "NEWFILE=%s"
 set "WORKDIR=%s"
+set "%s"
 cd /D "%%WORKDIR%%"
 :wait
 ping 127.0.0.1 -n 2 >nul 2>nul
@@ -198,7 +228,7 @@ if errorlevel 1 (
 start "" /b "%%TARGET%%"%s
 start "" /b cmd /c "del /q ""%%~f0"""
 exit /b
-`, escapeForBatch(targetPath), escapeForBatch(newPath), escapeForBatch(filepath.Dir(targetPath)), argString)
+`, escapeForBatch(targetPath), escapeForBatch(newPath), escapeForBatch(filepath.Dir(targetPath)), relaunchMarker(), argString)
 
 	if err := os.WriteFile(scriptPath, []byte(script), 0o644); err != nil {
 		return fmt.Errorf("write updater script: %w", err)
@@ -271,13 +301,9 @@ func compareVersions(a, b string) int {
 	return 0
 }
 
-func newHTTPClient(disableSSL bool, timeout time.Duration) *http.Client {
-	transport := &http.Transport{}
-	if disableSSL {
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
-	}
+func newHTTPClient(timeout time.Duration) *http.Client {
 	return &http.Client{
-		Transport: transport,
+		Transport: utils.SharedTransport(),
 		Timeout:   timeout,
 	}
 }
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type TransportSettings struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	InsecureSkipVerify  bool
+}
+
+func (s *TransportSettings) Default() {
+	if s.MaxIdleConnsPerHost <= 0 {
+		s.MaxIdleConnsPerHost = 16
+	}
+	if s.IdleConnTimeout <= 0 {
+		s.IdleConnTimeout = 90 * time.Second
+	}
+}
+
+var (
+	transportMu     sync.Mutex
+	sharedTransport *http.Transport
+)
+
+// ? ConfigureTransport replaces the transport returned by SharedTransport; call it once at startup, before any client is built.
+func ConfigureTransport(settings TransportSettings) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	sharedTransport = newTransport(settings)
+}
+
+// ? SharedTransport is the single connection pool used by every HTTP client, so GQL calls keep reusing warm TLS connections.
+func SharedTransport() *http.Transport {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if sharedTransport == nil {
+		sharedTransport = newTransport(TransportSettings{})
+	}
+	return sharedTransport
+}
+
+func newTransport(settings TransportSettings) *http.Transport {
+	settings.Default()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 4 * settings.MaxIdleConnsPerHost
+	transport.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = settings.IdleConnTimeout
+	if settings.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	return transport
+}
@@ -0,0 +1,67 @@
+package twitchchannelpointsminer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+// ? watchTimeReporter logs how long each streamer has been watched once per WatchTimeReport.
+func (m *Miner) watchTimeReporter(streamers []*entities.Streamer, stop <-chan struct{}) {
+	if m.WatchTimeReport <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.WatchTimeReport)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.logWatchTime(streamers)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *Miner) logWatchTime(streamers []*entities.Streamer) {
+	watched := make([]*entities.Streamer, 0, len(streamers))
+	var total time.Duration
+	for _, s := range streamers {
+		if s.WatchedSession < time.Minute {
+			continue
+		}
+		watched = append(watched, s)
+		total += s.WatchedSession
+	}
+	if len(watched) == 0 {
+		m.logger.EmojiPrintf(":hourglass:", "Watch time: nothing watched yet this session")
+		return
+	}
+	sort.SliceStable(watched, func(i, j int) bool { return watched[i].WatchedSession > watched[j].WatchedSession })
+	m.logger.EmojiPrintf(":hourglass:", "Watch time: %s across %d streamer(s)", formatWatchTime(total), len(watched))
+	for _, s := range watched {
+		current := "-"
+		if s.IsOnline && s.Stream != nil {
+			current = formatWatchTime(time.Duration(s.Stream.MinuteWatched * float64(time.Minute)))
+		}
+		rate := ""
+		if _, perHour, ok := s.WatchRate(); ok {
+			rate = fmt.Sprintf(", %.0f points/hour", perHour)
+		}
+		m.logger.Printf("                         %-24s session %-8s this stream %s%s", displayName(s), formatWatchTime(s.WatchedSession), current, rate)
+	}
+}
+
+// ? logWatchEvent records one credited minute-watched event for log_watch_events, to compare with Twitch's points history.
+func (m *Miner) logWatchEvent(s *entities.Streamer) {
+	m.logger.Auditf(
+		"Watch event: %s broadcast %s, %.0f min this stream, %s this session, spade 204 (URL %s old)",
+		s.Username,
+		s.Stream.BroadcastID,
+		s.Stream.MinuteWatched,
+		formatWatchTime(s.WatchedSession),
+		formatWatchTime(time.Since(s.Stream.SpadeFetched)),
+	)
+}
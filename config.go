@@ -0,0 +1,659 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/utils"
+)
+
+// ? currentConfigVersion is stamped into config.json; bump it together with a new configMigrations entry.
+const currentConfigVersion = 2
+
+type configMigration struct {
+	description string
+	apply       func(cfg map[string]interface{}, logf func(format string, args ...interface{}))
+}
+
+// ? configMigrations[i] upgrades a config from version i to i+1.
+var configMigrations = []configMigration{
+	{
+		description: "stamp config_version",
+		apply:       func(cfg map[string]interface{}, logf func(string, ...interface{})) {},
+	},
+	{
+		description: "rename betting(make_predictions) to make_predictions",
+		apply:       renameConfigKeys,
+	},
+}
+
+// ? renamedConfigKeys maps old top-level keys to their replacements. The old key stays in the file for one
+// ? version so a downgrade still reads it; the migration of the next version may delete it.
+var renamedConfigKeys = []struct{ old, new string }{
+	{old: "betting(make_predictions)", new: "make_predictions"},
+}
+
+// ? renameConfigKeys copies each renamed key's value to its new name. When both are set, the new key wins.
+func renameConfigKeys(cfg map[string]interface{}, logf func(format string, args ...interface{})) {
+	for _, rename := range renamedConfigKeys {
+		value, ok := cfg[rename.old]
+		if !ok {
+			continue
+		}
+		if current, exists := cfg[rename.new]; exists {
+			if fmt.Sprint(current) != fmt.Sprint(value) {
+				logf("config: both %s and %s are set; using %s", rename.old, rename.new, rename.new)
+			}
+			continue
+		}
+		cfg[rename.new] = value
+		logf("config: %s renamed to %s; the old key is ignored from now on", rename.old, rename.new)
+	}
+}
+
+type betConfig struct {
+	Strategy           string                     `json:"strategy"`
+	Percentage         *int                       `json:"percentage"`
+	PercentageGap      *int                       `json:"percentage_gap"`
+	SmartTiePrefer     string                     `json:"smart_tie_prefer"`
+	MaxPoints          *int                       `json:"max_points"`
+	StealthMode        *bool                      `json:"stealth_mode"`
+	DelayMode          string                     `json:"delay_mode"`
+	Delay              *float64                   `json:"delay"`
+	MinimumPoints      *int                       `json:"minimum_points"`
+	AllowSingleOutcome *bool                      `json:"allow_single_outcome"`
+	MinWindowForDelay  *float64                   `json:"min_window_for_delay_seconds"`
+	MaxBetsPerStream   *int                       `json:"max_bets_per_stream"`
+	MinOutcomeUsers    *int                       `json:"min_outcome_users"`
+	SkipUnaffordable   *bool                      `json:"skip_unaffordable"`
+	Momentum           *entities.MomentumSettings `json:"momentum"`
+	StrategyByCategory map[string]string          `json:"strategy_by_category"`
+	PercentageScale    *entities.PercentageScale  `json:"percentage_scale"`
+}
+
+// ? claimDropsStartup accepts either a bool or a list of campaign names; a list enables the claim for those campaigns only.
+type claimDropsStartup struct {
+	Enabled   bool
+	Campaigns []string
+}
+
+func (c *claimDropsStartup) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		*c = claimDropsStartup{Enabled: enabled}
+		return nil
+	}
+	var campaigns []string
+	if err := json.Unmarshal(data, &campaigns); err != nil {
+		return fmt.Errorf("claim_drops_startup must be true, false or a list of campaign names")
+	}
+	*c = claimDropsStartup{Enabled: len(campaigns) > 0, Campaigns: campaigns}
+	return nil
+}
+
+// ? streamerConfig holds per-streamer overrides; nil fields inherit the global value.
+type streamerConfig struct {
+	MakePredictions   *bool     `json:"make_predictions"`
+	FollowRaid        *bool     `json:"follow_raid"`
+	ClaimDrops        *bool     `json:"claim_drops"`
+	ClaimMoments      *bool     `json:"claim_moments"`
+	ClaimBonus        *bool     `json:"claim_bonus"`
+	WatchStreak       *bool     `json:"watch_streak"`
+	CommunityGoals    *bool     `json:"community_goals"`
+	PointsReserve     *int      `json:"points_reserve"`
+	BetOnlyIfWatching *bool     `json:"bet_only_if_watching"`
+	HouseMoneyOnly    *bool     `json:"house_money_only"`
+	MinViewersToWatch *int      `json:"min_viewers_to_watch"`
+	NotifyTarget      *string   `json:"notify_target"`
+	NotifyEvents      *[]string `json:"notify_events"`
+	Bet               betConfig `json:"bet"`
+}
+
+type notifyConfig struct {
+	WebhookURL        string                        `json:"webhook_url"`
+	DiscordWebhookURL string                        `json:"discord_webhook_url"`
+	TelegramToken     string                        `json:"telegram_token"`
+	TelegramChatID    string                        `json:"telegram_chat_id"`
+	Templates         map[string]string             `json:"templates"`
+	Targets           map[string]notifyTargetConfig `json:"targets"`
+}
+
+type notifyTargetConfig struct {
+	WebhookURL        string `json:"webhook_url"`
+	DiscordWebhookURL string `json:"discord_webhook_url"`
+	TelegramToken     string `json:"telegram_token"`
+	TelegramChatID    string `json:"telegram_chat_id"`
+}
+
+type config struct {
+	Username                   string                    `json:"username"`
+	Password                   string                    `json:"password"`
+	SafeMode                   bool                      `json:"safe_mode"`
+	AutoUpdate                 bool                      `json:"auto_update"`
+	Debug                      bool                      `json:"debug"`
+	DebugHTTP                  bool                      `json:"debug_http"`
+	SmartLogging               bool                      `json:"smart_logging"`
+	SmartLoggingWindowSeconds  int                       `json:"smart_logging_window_seconds"`
+	LogWatchEvents             bool                      `json:"log_watch_events"`
+	DisableSSLCertVerification bool                      `json:"disable_ssl_cert_verification"`
+	GQLMaxConcurrent           int                       `json:"gql_max_concurrent"`
+	WatchMaxConcurrent         int                       `json:"watch_max_concurrent"`
+	HTTPMaxIdleConnsPerHost    int                       `json:"http_max_idle_conns_per_host"`
+	HTTPIdleTimeoutSeconds     int                       `json:"http_idle_timeout_seconds"`
+	SpadeMaxAgeMinutes         int                       `json:"spade_max_age_minutes"`
+	MaxAuthFailures            int                       `json:"max_auth_failures"`
+	ExitOnAuthFailure          bool                      `json:"exit_on_auth_failure"`
+	RestartOnPanic             bool                      `json:"restart_on_panic"`
+	TimerJitterMinutes         float64                   `json:"timer_jitter_minutes"`
+	MaxRuntimeMinutes          float64                   `json:"max_runtime_minutes"`
+	SpadeExtraProps            map[string]interface{}    `json:"spade_extra_props"`
+	WatchProfile               string                    `json:"watch_profile"`
+	ShowSeconds                bool                      `json:"show_seconds"`
+	ClaimDropsStartup          claimDropsStartup         `json:"claim_drops_startup"`
+	ClaimDrops                 bool                      `json:"claim_drops"`
+	ClaimBonusOnLoad           bool                      `json:"claim_bonus_on_load"`
+	PersistClaimedDrops        bool                      `json:"persist_claimed_drops"`
+	DropsRewardWhitelist       []string                  `json:"drops_reward_whitelist"`
+	DropsExpiryWarnHours       float64                   `json:"drops_expiry_warn_hours"`
+	MakePredictions            bool                      `json:"make_predictions"`
+	MaxPendingPredictions      int                       `json:"max_pending_predictions"`
+	ConfirmBetViaGQL           bool                      `json:"confirm_bet_via_gql"`
+	FollowRaid                 bool                      `json:"follow_raid"`
+	RaidJoinCooldownMinutes    float64                   `json:"raid_join_cooldown_minutes"`
+	PresenceGraceSeconds       int                       `json:"presence_grace_seconds"`
+	PubSubPingMinSeconds       int                       `json:"pubsub_ping_min_seconds"`
+	PubSubPingMaxSeconds       int                       `json:"pubsub_ping_max_seconds"`
+	PubSubPongTimeoutSeconds   int                       `json:"pubsub_pong_timeout_seconds"`
+	PointsDedupSeconds         int                       `json:"points_dedup_seconds"`
+	CommunityGoals             bool                      `json:"community_goals"`
+	CommunityGoalRetries       int                       `json:"community_goal_retries"`
+	PointsReserve              int                       `json:"points_reserve"`
+	BetOnlyIfWatching          bool                      `json:"bet_only_if_watching"`
+	HouseMoneyOnly             bool                      `json:"house_money_only"`
+	MinViewersToWatch          int                       `json:"min_viewers_to_watch"`
+	Emojis                     bool                      `json:"emojis"`
+	SaveLogs                   bool                      `json:"save_logs"`
+	LogOutput                  string                    `json:"log_output"`
+	LogMaxLinesPerSecond       int                       `json:"log_max_lines_per_second"`
+	TUIMode                    bool                      `json:"tui_mode"`
+	ShowUsernameInConsole      bool                      `json:"show_username_in_console"`
+	ShowClaimedBonusMsg        bool                      `json:"show_claimed_bonus_msg"`
+	DropLogLevel               string                    `json:"drop_log_level"`
+	BalanceSyncLogThreshold    int                       `json:"balance_sync_log_threshold"`
+	SummarySort                string                    `json:"summary_sort"`
+	SummaryJSON                string                    `json:"summary_json"`
+	SessionBaseline            string                    `json:"session_baseline"`
+	WatchTimeReportMinutes     float64                   `json:"watch_time_report_minutes"`
+	BalanceCSV                 string                    `json:"balance_csv"`
+	BetLog                     string                    `json:"bet_log"`
+	BalanceSampleSeconds       int                       `json:"balance_sample_seconds"`
+	Streamers                  []string                  `json:"streamers"`
+	MineFollowersToo           bool                      `json:"mine_followers_too"`
+	FollowersFallback          bool                      `json:"followers_fallback"`
+	OnNoStreamers              string                    `json:"on_no_streamers"`
+	StartupLoadRetries         int                       `json:"startup_load_retries"`
+	StreamersSettings          map[string]streamerConfig `json:"streamers_settings"`
+	WatchPriority              []string                  `json:"watch_priority"`
+	StreakCatchupAfterMinutes  float64                   `json:"streak_catchup_after_minutes"`
+	StreakMinPoints            int                       `json:"streak_min_points"`
+	Bet                        betConfig                 `json:"bet"`
+	Notify                     notifyConfig              `json:"notify"`
+}
+
+func defaultConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"config_version":                currentConfigVersion,
+		"username":                      "your-twitch-username",
+		"password":                      "your-twitch-password (Optional)",
+		"safe_mode":                     false,
+		"auto_update":                   true,
+		"debug":                         false,
+		"debug_http":                    false,
+		"smart_logging":                 true,
+		"smart_logging_window_seconds":  60,
+		"log_watch_events":              false,
+		"disable_ssl_cert_verification": false,
+		"gql_max_concurrent":            16,
+		"watch_max_concurrent":          1,
+		"http_max_idle_conns_per_host":  16,
+		"http_idle_timeout_seconds":     90,
+		"spade_max_age_minutes":         60,
+		"max_auth_failures":             10,
+		"exit_on_auth_failure":          false,
+		"restart_on_panic":              true,
+		"timer_jitter_minutes":          0,
+		"max_runtime_minutes":           0,
+		"spade_extra_props":             map[string]interface{}{},
+		"watch_profile":                 "site",
+		"show_seconds":                  false,
+		"claim_drops_startup":           true,
+		"claim_drops":                   true,
+		"claim_bonus_on_load":           true,
+		"persist_claimed_drops":         true,
+		"drops_reward_whitelist":        []interface{}{},
+		"drops_expiry_warn_hours":       0,
+		"make_predictions":              true,
+		"max_pending_predictions":       50,
+		"confirm_bet_via_gql":           false,
+		"follow_raid":                   true,
+		"raid_join_cooldown_minutes":    0,
+		"presence_grace_seconds":        30,
+		"pubsub_ping_min_seconds":       25,
+		"pubsub_ping_max_seconds":       30,
+		"pubsub_pong_timeout_seconds":   300,
+		"points_dedup_seconds":          120,
+		"community_goals":               false,
+		"community_goal_retries":        3,
+		"points_reserve":                0,
+		"bet_only_if_watching":          false,
+		"house_money_only":              false,
+		"min_viewers_to_watch":          0,
+		"emojis":                        true,
+		"save_logs":                     false,
+		"log_output":                    "stdout",
+		"log_max_lines_per_second":      0,
+		"tui_mode":                      false,
+		"show_username_in_console":      false,
+		"show_claimed_bonus_msg":        true,
+		"drop_log_level":                "full",
+		"balance_sync_log_threshold":    0,
+		"summary_sort":                  "gain",
+		"summary_json":                  "",
+		"session_baseline":              "session",
+		"watch_time_report_minutes":     0,
+		"balance_csv":                   "",
+		"bet_log":                       "",
+		"balance_sample_seconds":        60,
+		"streamers":                     []interface{}{},
+		"mine_followers_too":            false,
+		"followers_fallback":            true,
+		"on_no_streamers":               "exit",
+		"startup_load_retries":          3,
+		"streamers_settings":            map[string]interface{}{},
+		"streak_catchup_after_minutes":  0,
+		"streak_min_points":             0,
+		"watch_priority": []interface{}{
+			"STREAK",
+			"DROPS",
+			"ORDER",
+		},
+		"bet": map[string]interface{}{
+			"strategy":                     nil,
+			"percentage":                   nil,
+			"percentage_gap":               nil,
+			"smart_tie_prefer":             nil,
+			"max_points":                   nil,
+			"stealth_mode":                 nil,
+			"delay_mode":                   nil,
+			"delay":                        nil,
+			"minimum_points":               nil,
+			"allow_single_outcome":         nil,
+			"min_window_for_delay_seconds": nil,
+			"max_bets_per_stream":          nil,
+			"min_outcome_users":            nil,
+			"skip_unaffordable":            nil,
+			"momentum":                     nil,
+			"strategy_by_category":         nil,
+			"percentage_scale":             nil,
+		},
+		"notify": map[string]interface{}{
+			"webhook_url":         "",
+			"discord_webhook_url": "",
+			"telegram_token":      "",
+			"telegram_chat_id":    "",
+			"templates":           map[string]interface{}{},
+			"targets":             map[string]interface{}{},
+		},
+	}
+}
+
+// ? nestedConfigBlocks get missing sub-keys filled from defaultConfig, not just missing top-level keys.
+var nestedConfigBlocks = []string{"bet", "notify"}
+
+// ? loadOrCreateConfig reads JSON, YAML or TOML by extension. Missing files are created with defaults in that format.
+// ? Existing JSON files get missing keys written back; YAML and TOML files are left untouched so hand-written comments survive.
+func loadOrCreateConfig(path string) (config, error) {
+	format, err := configFormatFor(path)
+	if err != nil {
+		return config{}, err
+	}
+	cfgMap := map[string]interface{}{}
+	fileData, err := os.ReadFile(path)
+	if err == nil {
+		if cfgMap, err = decodeConfig(format, fileData); err != nil {
+			return config{}, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	changed := false
+	if err == nil {
+		// ? only JSON is rewritten, so YAML and TOML files are migrated in memory on every start; their
+		// ? step-by-step log would repeat each time and is replaced by one line below
+		persisted := format == configFormatJSON
+		logf := func(string, ...interface{}) {}
+		if persisted {
+			logf = log.Printf
+		}
+		from, migrated := migrateConfig(cfgMap, logf)
+		changed = migrated && persisted
+		if migrated && !persisted {
+			log.Printf("config: %s has config_version %d; applied the migrations to v%d in memory (%s files are not rewritten)", path, from, currentConfigVersion, strings.ToUpper(string(format)))
+		}
+	}
+	for key, value := range defaultConfig() {
+		if _, ok := cfgMap[key]; !ok {
+			cfgMap[key] = value
+			changed = true
+		}
+	}
+
+	for _, block := range nestedConfigBlocks {
+		defaults := defaultConfig()[block].(map[string]interface{})
+		raw, ok := cfgMap[block].(map[string]interface{})
+		if !ok {
+			cfgMap[block] = defaults
+			changed = true
+			continue
+		}
+		for k, v := range defaults {
+			if _, ok := raw[k]; !ok {
+				raw[k] = v
+				changed = changed || v != nil || format.supportsNull()
+			}
+		}
+	}
+
+	switch {
+	case err != nil:
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return config{}, err
+		}
+		raw, err := encodeConfig(format, cfgMap)
+		if err != nil {
+			return config{}, err
+		}
+		if err := os.WriteFile(path, raw, 0o644); err != nil {
+			return config{}, err
+		}
+	case changed && format == configFormatJSON:
+		if err := utils.SaveJSON(path, cfgMap); err != nil {
+			return config{}, err
+		}
+	case changed:
+		log.Printf("config: %s is missing newer keys; using their defaults without rewriting the file", path)
+	}
+
+	normalized, err := json.Marshal(cfgMap)
+	if err != nil {
+		return config{}, err
+	}
+	var cfg config
+	if err := json.Unmarshal(normalized, &cfg); err != nil {
+		return config{}, err
+	}
+	return cfg, nil
+}
+
+func (b betConfig) apply(base entities.BetSettings) entities.BetSettings {
+	if b.Strategy != "" {
+		base.Strategy = entities.Strategy(b.Strategy)
+	}
+	if b.Percentage != nil {
+		base.Percentage = b.Percentage
+	}
+	if b.PercentageGap != nil {
+		base.PercentageGap = b.PercentageGap
+	}
+	// ? 0 keeps the inherited cap, like null
+	if b.MaxPoints != nil && *b.MaxPoints > 0 {
+		base.MaxPoints = b.MaxPoints
+	}
+	if b.StealthMode != nil {
+		base.StealthMode = b.StealthMode
+	}
+	if b.SmartTiePrefer != "" {
+		base.SmartTiePrefer = entities.TiePrefer(b.SmartTiePrefer)
+	}
+	if b.DelayMode != "" {
+		base.DelayMode = entities.DelayMode(b.DelayMode)
+	}
+	if b.Delay != nil {
+		base.Delay = b.Delay
+	}
+	if b.MinimumPoints != nil {
+		base.MinimumPoints = b.MinimumPoints
+	}
+	if b.AllowSingleOutcome != nil {
+		base.AllowSingleOutcome = b.AllowSingleOutcome
+	}
+	if b.MinWindowForDelay != nil {
+		base.MinWindowForDelay = b.MinWindowForDelay
+	}
+	if b.MaxBetsPerStream != nil {
+		base.MaxBetsPerStream = b.MaxBetsPerStream
+	}
+	if b.MinOutcomeUsers != nil {
+		base.MinOutcomeUsers = b.MinOutcomeUsers
+	}
+	if b.PercentageScale != nil {
+		base.PercentageScale = b.PercentageScale
+	}
+	if b.StrategyByCategory != nil {
+		byCategory := make(map[string]entities.Strategy, len(b.StrategyByCategory))
+		for category, strategy := range b.StrategyByCategory {
+			byCategory[category] = entities.Strategy(strings.ToUpper(strings.TrimSpace(strategy)))
+		}
+		base.StrategyByCategory = byCategory
+	}
+	if b.SkipUnaffordable != nil {
+		base.SkipUnaffordable = b.SkipUnaffordable
+	}
+	if b.Momentum != nil {
+		momentum := entities.MomentumSettings{}
+		if base.Momentum != nil {
+			momentum = *base.Momentum
+		}
+		if b.Momentum.OnWin != nil {
+			momentum.OnWin = b.Momentum.OnWin
+		}
+		if b.Momentum.OnLoss != nil {
+			momentum.OnLoss = b.Momentum.OnLoss
+		}
+		base.Momentum = &momentum
+	}
+	return base
+}
+
+func (c streamerConfig) apply(base entities.StreamerSettings) entities.StreamerSettings {
+	if c.MakePredictions != nil {
+		base.MakePredictions = *c.MakePredictions
+	}
+	if c.FollowRaid != nil {
+		base.FollowRaid = *c.FollowRaid
+	}
+	if c.ClaimDrops != nil {
+		base.ClaimDrops = *c.ClaimDrops
+	}
+	if c.ClaimMoments != nil {
+		base.ClaimMoments = *c.ClaimMoments
+	}
+	if c.ClaimBonus != nil {
+		base.ClaimBonus = *c.ClaimBonus
+	}
+	if c.WatchStreak != nil {
+		base.WatchStreak = *c.WatchStreak
+	}
+	if c.CommunityGoals != nil {
+		base.CommunityGoals = *c.CommunityGoals
+	}
+	if c.PointsReserve != nil {
+		base.PointsReserve = *c.PointsReserve
+	}
+	if c.BetOnlyIfWatching != nil {
+		base.BetOnlyIfWatching = *c.BetOnlyIfWatching
+	}
+	if c.HouseMoneyOnly != nil {
+		base.HouseMoneyOnly = *c.HouseMoneyOnly
+	}
+	if c.MinViewersToWatch != nil {
+		base.MinViewersToWatch = *c.MinViewersToWatch
+	}
+	if c.NotifyTarget != nil {
+		base.NotifyTarget = *c.NotifyTarget
+	}
+	if c.NotifyEvents != nil {
+		// ? non-nil even when empty, so [] disables the channel's notifications instead of inheriting
+		base.NotifyEvents = append([]string{}, *c.NotifyEvents...)
+	}
+	base.Bet = c.Bet.apply(base.Bet)
+	base.Default()
+	return base
+}
+
+// ? perStreamerSettings resolves streamers_settings on top of the global settings, keyed by lowercase login.
+func perStreamerSettings(cfg config, base entities.StreamerSettings) map[string]entities.StreamerSettings {
+	resolved := make(map[string]entities.StreamerSettings, len(cfg.StreamersSettings))
+	for name, override := range cfg.StreamersSettings {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		resolved[name] = override.apply(base)
+	}
+	return resolved
+}
+
+// ? validateMaxPoints rejects a bet.max_points below Twitch's minimum stake wherever betting is on; every bet would be skipped.
+func validateMaxPoints(global entities.StreamerSettings, overrides map[string]entities.StreamerSettings) error {
+	var bad []string
+	check := func(key string, settings entities.StreamerSettings) {
+		if settings.MakePredictions && settings.Bet.MaxPoints != nil && *settings.Bet.MaxPoints < entities.MinBetPoints {
+			bad = append(bad, fmt.Sprintf("%s is %d", key, *settings.Bet.MaxPoints))
+		}
+	}
+	check("bet.max_points", global)
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check(fmt.Sprintf("streamers_settings.%s.bet.max_points", name), overrides[name])
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s, below Twitch's minimum bet of %d, so no bet could ever be placed; raise it, use 0 for the default, or turn make_predictions off", strings.Join(bad, ", "), entities.MinBetPoints)
+}
+
+// ? migrateConfig runs every migration between the file's config_version and the current one, reporting each step
+// ? through logf. It returns the version the map started at and whether anything was migrated.
+func migrateConfig(cfgMap map[string]interface{}, logf func(format string, args ...interface{})) (int, bool) {
+	version := 0
+	if raw, ok := cfgMap["config_version"].(float64); ok {
+		version = int(raw)
+	}
+	from := version
+	if version > currentConfigVersion {
+		log.Printf("config: version %d is newer than supported version %d; leaving it untouched", version, currentConfigVersion)
+		return from, false
+	}
+	if version == currentConfigVersion {
+		return from, false
+	}
+	for version < currentConfigVersion {
+		migration := configMigrations[version]
+		migration.apply(cfgMap, logf)
+		logf("config: migrated v%d -> v%d (%s)", version, version+1, migration.description)
+		version++
+	}
+	cfgMap["config_version"] = version
+	return from, true
+}
+
+// ? applySafeMode turns off everything safe_mode considers risky and logs what it changed.
+func applySafeMode(cfg *config) {
+	var disabled []string
+	if cfg.AutoUpdate {
+		cfg.AutoUpdate = false
+		disabled = append(disabled, "auto_update")
+	}
+	if cfg.MakePredictions {
+		cfg.MakePredictions = false
+		disabled = append(disabled, "make_predictions")
+	}
+	if cfg.CommunityGoals {
+		cfg.CommunityGoals = false
+		disabled = append(disabled, "community_goals")
+	}
+	for name, override := range cfg.StreamersSettings {
+		if override.MakePredictions != nil && *override.MakePredictions {
+			override.MakePredictions = nil
+			disabled = append(disabled, fmt.Sprintf("streamers_settings.%s.make_predictions", name))
+		}
+		if override.CommunityGoals != nil && *override.CommunityGoals {
+			override.CommunityGoals = nil
+			disabled = append(disabled, fmt.Sprintf("streamers_settings.%s.community_goals", name))
+		}
+		cfg.StreamersSettings[name] = override
+	}
+	if len(disabled) > 0 {
+		log.Printf("safe mode: disabled %s", strings.Join(disabled, ", "))
+	}
+	log.Printf("safe mode: PubSub reconnect backoff raised to %s", safeModeReconnectDelay)
+}
+
+// ? maxPubSubPingSeconds keeps pings inside the 5 minutes after which Twitch drops a silent PubSub connection.
+const maxPubSubPingSeconds = 240
+
+// ? validatePubSubTiming rejects ping intervals Twitch would disconnect over and a PONG timeout that would
+// ? reconnect before a PONG could arrive.
+func validatePubSubTiming(cfg config) error {
+	minPing, maxPing := cfg.PubSubPingMinSeconds, cfg.PubSubPingMaxSeconds
+	if minPing < 1 || maxPing < minPing {
+		return fmt.Errorf("pubsub_ping_min_seconds (%d) must be at least 1 and not above pubsub_ping_max_seconds (%d)", minPing, maxPing)
+	}
+	if maxPing > maxPubSubPingSeconds {
+		return fmt.Errorf("pubsub_ping_max_seconds (%d) must be at most %d; Twitch drops connections that don't ping within 5 minutes", maxPing, maxPubSubPingSeconds)
+	}
+	if timeout := cfg.PubSubPongTimeoutSeconds; timeout < 0 || (timeout > 0 && timeout <= maxPing) {
+		return fmt.Errorf("pubsub_pong_timeout_seconds (%d) must be 0 (off) or above pubsub_ping_max_seconds (%d)", timeout, maxPing)
+	}
+	return nil
+}
+
+// ? dropLogLevel validates drop_log_level, falling back to "full" for unknown values.
+func dropLogLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch level {
+	case "full", "summary", "silent":
+		return level
+	case "":
+		return "full"
+	}
+	log.Printf("config: unknown drop_log_level %q, using \"full\"", level)
+	return "full"
+}
+
+// ? watchProfile validates watch_profile, falling back to "site" for unknown names and warning about experimental ones.
+func watchProfile(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "site"
+	}
+	if _, ok := classes.WatchProfiles[name]; !ok {
+		log.Printf("config: unknown watch_profile %q, using \"site\"", name)
+		return "site"
+	}
+	if name != "site" {
+		log.Printf("config: watch_profile %q is experimental; check that watch streaks and watch bonuses are still credited", name)
+	}
+	return name
+}
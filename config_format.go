@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type configFormat string
+
+const (
+	configFormatJSON configFormat = "json"
+	configFormatYAML configFormat = "yaml"
+	configFormatTOML configFormat = "toml"
+)
+
+// ? supportsNull is false for TOML, where unset defaults cannot be written and are simply absent.
+func (f configFormat) supportsNull() bool {
+	return f != configFormatTOML
+}
+
+// ? configCandidates are probed in order when no --config flag is given; config.json stays the default.
+var configCandidates = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// ? resolveConfigPath returns the explicit path when set, otherwise the first existing candidate, otherwise config.json.
+func resolveConfigPath(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	for _, candidate := range configCandidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return configCandidates[0]
+}
+
+func configFormatFor(path string) (configFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return configFormatJSON, nil
+	case ".yaml", ".yml":
+		return configFormatYAML, nil
+	case ".toml":
+		return configFormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported config extension %q (use .json, .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+}
+
+// ? decodeConfig parses any supported format into the generic map used by loadOrCreateConfig.
+// ? The JSON round-trip gives every format the same value types (float64 numbers, map[string]interface{} objects).
+func decodeConfig(format configFormat, data []byte) (map[string]interface{}, error) {
+	cfgMap := map[string]interface{}{}
+	var err error
+	switch format {
+	case configFormatYAML:
+		err = yaml.Unmarshal(data, &cfgMap)
+	case configFormatTOML:
+		err = toml.Unmarshal(data, &cfgMap)
+	default:
+		return cfgMap, json.Unmarshal(data, &cfgMap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(cfgMap)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// ? encodeConfig serializes the map for a freshly created config file. TOML has no null, so unset values are omitted.
+func encodeConfig(format configFormat, cfgMap map[string]interface{}) ([]byte, error) {
+	switch format {
+	case configFormatYAML:
+		return yaml.Marshal(cfgMap)
+	case configFormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(cfgMap); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return json.MarshalIndent(cfgMap, "", "  ")
+	}
+}
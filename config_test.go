@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+)
+
+func TestPerStreamerMaxPoints(t *testing.T) {
+	raw := `{
+		"make_predictions": true,
+		"bet": {"strategy": "MOST_VOTED", "percentage": 10, "max_points": 50000},
+		"streamers_settings": {
+			"Volatile": {"bet": {"max_points": 200}},
+			"trusted": {"bet": {"max_points": 5000}}
+		}
+	}`
+	var cfg config
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	bet := cfg.Bet.apply(entities.BetSettings{})
+	bet.Default()
+	global := entities.StreamerSettings{MakePredictions: cfg.MakePredictions, Bet: bet}
+	overrides := perStreamerSettings(cfg, global)
+	if err := validateMaxPoints(global, overrides); err != nil {
+		t.Fatal(err)
+	}
+
+	const balance = 100000
+	tests := []struct {
+		name     string
+		settings entities.StreamerSettings
+		want     int
+	}{
+		{"global cap", global, 10000},
+		{"small override", overrides["volatile"], 200},
+		{"large override", overrides["trusted"], 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &classes.PredictionEvent{
+				Streamer: &entities.Streamer{Username: "x", ChannelPoints: balance, Settings: tt.settings},
+				Outcomes: []classes.PredictionOutcome{
+					{ID: "a", TotalUsers: 10, TotalPoints: 1000},
+					{ID: "b", TotalUsers: 5, TotalPoints: 1000},
+				},
+			}
+			if got := event.Decide(balance).Amount; got != tt.want {
+				t.Errorf("stake = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,4 +2,8 @@ module TwitchChannelPointsMiner
 
 go 1.21
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/gorilla/websocket v1.5.3
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,51 +1,22 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	miner "TwitchChannelPointsMiner/TwitchChannelPointsMiner"
 	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/classes/entities"
+	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/constants"
 	"TwitchChannelPointsMiner/TwitchChannelPointsMiner/utils"
 )
 
-type betConfig struct {
-	Strategy      string   `json:"strategy"`
-	Percentage    *int     `json:"percentage"`
-	PercentageGap *int     `json:"percentage_gap"`
-	MaxPoints     *int     `json:"max_points"`
-	StealthMode   *bool    `json:"stealth_mode"`
-	DelayMode     string   `json:"delay_mode"`
-	Delay         *float64 `json:"delay"`
-	MinimumPoints *int     `json:"minimum_points"`
-}
-
-type config struct {
-	Username                   string    `json:"username"`
-	Password                   string    `json:"password"`
-	AutoUpdate                 bool      `json:"auto_update"`
-	Debug                      bool      `json:"debug"`
-	SmartLogging               bool      `json:"smart_logging"`
-	DisableSSLCertVerification bool      `json:"disable_ssl_cert_verification"`
-	ShowSeconds                bool      `json:"show_seconds"`
-	ClaimDropsStartup          bool      `json:"claim_drops_startup"`
-	ClaimDrops                 bool      `json:"claim_drops"`
-	BettingMakePredictions     bool      `json:"betting(make_predictions)"`
-	FollowRaid                 bool      `json:"follow_raid"`
-	CommunityGoals             bool      `json:"community_goals"`
-	Emojis                     bool      `json:"emojis"`
-	SaveLogs                   bool      `json:"save_logs"`
-	ShowUsernameInConsole      bool      `json:"show_username_in_console"`
-	ShowClaimedBonusMsg        bool      `json:"show_claimed_bonus_msg"`
-	Streamers                  []string  `json:"streamers"`
-	WatchPriority              []string  `json:"watch_priority"`
-	Bet                        betConfig `json:"bet"`
-}
+const safeModeReconnectDelay = time.Minute
 
 func clearConsole() {
 	var c *exec.Cmd
@@ -69,104 +40,37 @@ func setConsoleTitle(title string) {
 	_ = cmd.Run()
 }
 
-func defaultConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"username":                      "your-twitch-username",
-		"password":                      "your-twitch-password (Optional)",
-		"auto_update":                   true,
-		"debug":                         false,
-		"smart_logging":                 true,
-		"disable_ssl_cert_verification": false,
-		"show_seconds":                  false,
-		"claim_drops_startup":           true,
-		"claim_drops":                   true,
-		"betting(make_predictions)":     true,
-		"follow_raid":                   true,
-		"community_goals":               false,
-		"emojis":                        true,
-		"save_logs":                     false,
-		"show_username_in_console":      false,
-		"show_claimed_bonus_msg":        true,
-		"streamers":                     []interface{}{},
-		"watch_priority": []interface{}{
-			"STREAK",
-			"DROPS",
-			"ORDER",
-		},
-		"bet": map[string]interface{}{
-			"strategy":       nil,
-			"percentage":     nil,
-			"percentage_gap": nil,
-			"max_points":     nil,
-			"stealth_mode":   nil,
-			"delay_mode":     nil,
-			"delay":          nil,
-			"minimum_points": nil,
-		},
-	}
-}
-
-func loadOrCreateConfig(path string) (config, error) {
-	cfgMap := map[string]interface{}{}
-	fileData, err := os.ReadFile(path)
-	if err == nil {
-		if err := json.Unmarshal(fileData, &cfgMap); err != nil {
-			return config{}, fmt.Errorf("invalid config: %w", err)
-		}
-	}
-
-	changed := false
-	for key, value := range defaultConfig() {
-		if _, ok := cfgMap[key]; !ok {
-			cfgMap[key] = value
-			changed = true
-		}
-	}
-
-	betRaw, ok := cfgMap["bet"].(map[string]interface{})
-	if !ok {
-		betRaw = defaultConfig()["bet"].(map[string]interface{})
-		cfgMap["bet"] = betRaw
-		changed = true
-	} else {
-		for k, v := range defaultConfig()["bet"].(map[string]interface{}) {
-			if _, ok := betRaw[k]; !ok {
-				betRaw[k] = v
-				changed = true
-			}
-		}
-	}
-
-	if err != nil || changed {
-		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-			return config{}, err
-		}
-		if err := utils.SaveJSON(path, cfgMap); err != nil {
-			return config{}, err
-		}
-	}
-
-	normalized, err := json.Marshal(cfgMap)
-	if err != nil {
-		return config{}, err
-	}
-	var cfg config
-	if err := json.Unmarshal(normalized, &cfg); err != nil {
-		return config{}, err
-	}
-	return cfg, nil
-}
-
 func main() {
+	configPath := flag.String("config", "", "config file (.json, .yaml, .yml or .toml); defaults to the first of config.json, config.yaml, config.yml, config.toml that exists")
+	claimAll := flag.Bool("claim-all", false, "claim every pending bonus chest and inventory drop once, then exit without mining")
+	flag.Parse()
+
 	setConsoleTitle("Klaro's Twitch Miner")
 	clearConsole()
-	cfg, err := loadOrCreateConfig("config.json")
+	cfg, err := loadOrCreateConfig(resolveConfigPath(*configPath))
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	if cfg.ShowUsernameInConsole && cfg.Username != "" {
+		setConsoleTitle(fmt.Sprintf("Klaro's Twitch Miner - %s", cfg.Username))
+	}
+	if cfg.SafeMode {
+		applySafeMode(&cfg)
+	}
+	if applied, err := constants.ApplyGQLOverrides("gql_overrides.json"); err != nil {
+		log.Printf("gql overrides ignored: %v", err)
+	} else if len(applied) > 0 {
+		log.Printf("gql overrides applied: %s", strings.Join(applied, ", "))
+	}
+
+	utils.ConfigureTransport(utils.TransportSettings{
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
+		InsecureSkipVerify:  cfg.DisableSSLCertVerification,
+	})
 
 	if cfg.AutoUpdate {
-		updated, err := miner.RunAutoUpdate(cfg.DisableSSLCertVerification)
+		updated, err := miner.RunAutoUpdate()
 		if err != nil {
 			log.Printf("auto-update failed: %v", err)
 		}
@@ -176,53 +80,122 @@ func main() {
 		}
 	}
 
-	betSettings := entities.BetSettings{
-		Strategy:      entities.Strategy(cfg.Bet.Strategy),
-		Percentage:    cfg.Bet.Percentage,
-		PercentageGap: cfg.Bet.PercentageGap,
-		MaxPoints:     cfg.Bet.MaxPoints,
-		StealthMode:   cfg.Bet.StealthMode,
-		DelayMode:     entities.DelayMode(cfg.Bet.DelayMode),
-		Delay:         cfg.Bet.Delay,
-		MinimumPoints: cfg.Bet.MinimumPoints,
-	}
+	betSettings := cfg.Bet.apply(entities.BetSettings{})
 	betSettings.Default()
 
 	streamerSettings := entities.StreamerSettings{
-		MakePredictions: cfg.BettingMakePredictions,
-		FollowRaid:      cfg.FollowRaid,
-		ClaimDrops:      cfg.ClaimDrops,
-		ClaimMoments:    true,
-		WatchStreak:     true,
-		CommunityGoals:  cfg.CommunityGoals,
-		Bet:             betSettings,
+		MakePredictions:   cfg.MakePredictions,
+		FollowRaid:        cfg.FollowRaid,
+		ClaimDrops:        cfg.ClaimDrops,
+		ClaimMoments:      true,
+		ClaimBonus:        true,
+		WatchStreak:       true,
+		CommunityGoals:    cfg.CommunityGoals,
+		BetOnlyIfWatching: cfg.BetOnlyIfWatching,
+		HouseMoneyOnly:    cfg.HouseMoneyOnly,
+		MinViewersToWatch: cfg.MinViewersToWatch,
+		PointsReserve:     cfg.PointsReserve,
+		Bet:               betSettings,
 	}
 	streamerSettings.Default()
 
 	loggerSettings := miner.LoggerSettings{
-		Save:             cfg.SaveLogs,
-		ConsoleLevel:     0,
-		FileLevel:        0,
-		Emoji:            cfg.Emojis,
-		Smart:            cfg.SmartLogging,
-		ShowSeconds:      cfg.ShowSeconds,
-		ConsoleUsername:  cfg.ShowUsernameInConsole,
-		ShowClaimedBonus: cfg.ShowClaimedBonusMsg,
-		Less:             false,
-		Debug:            cfg.Debug,
+		Save:              cfg.SaveLogs,
+		ConsoleLevel:      0,
+		FileLevel:         0,
+		Emoji:             cfg.Emojis,
+		Smart:             cfg.SmartLogging,
+		SmartWindow:       time.Duration(cfg.SmartLoggingWindowSeconds) * time.Second,
+		ShowSeconds:       cfg.ShowSeconds,
+		ConsoleUsername:   cfg.ShowUsernameInConsole,
+		ShowClaimedBonus:  cfg.ShowClaimedBonusMsg,
+		DropLogLevel:      dropLogLevel(cfg.DropLogLevel),
+		Less:              false,
+		Debug:             cfg.Debug,
+		MaxLinesPerSecond: cfg.LogMaxLinesPerSecond,
+		Output:            cfg.LogOutput,
+		TUI:               cfg.TUIMode,
 	}
 
 	minr := miner.NewMiner(
 		cfg.Username,
 		cfg.Password,
-		cfg.ClaimDropsStartup,
+		cfg.ClaimDropsStartup.Enabled,
 		cfg.DisableSSLCertVerification,
 		loggerSettings,
 		streamerSettings,
 		cfg.WatchPriority,
 	)
+	minr.StreamerOverrides = perStreamerSettings(cfg, minr.StreamerSettings)
+	if err := validateMaxPoints(minr.StreamerSettings, minr.StreamerOverrides); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	minr.BalanceSyncThreshold = cfg.BalanceSyncLogThreshold
+	minr.BalanceCSVPath = cfg.BalanceCSV
+	minr.SummarySort = cfg.SummarySort
+	minr.SummaryJSONPath = cfg.SummaryJSON
+	minr.WatchTimeReport = time.Duration(cfg.WatchTimeReportMinutes * float64(time.Minute))
+	minr.ClaimDropsStartupCampaigns = cfg.ClaimDropsStartup.Campaigns
+	minr.DropsExpiryWarn = time.Duration(cfg.DropsExpiryWarnHours * float64(time.Hour))
+	minr.BalanceSampleInterval = time.Duration(cfg.BalanceSampleSeconds) * time.Second
+	minr.TimerJitter = time.Duration(cfg.TimerJitterMinutes * float64(time.Minute))
+	minr.MaxRuntime = time.Duration(cfg.MaxRuntimeMinutes * float64(time.Minute))
+	minr.NotifySettings = miner.NotifySettings{
+		WebhookURL:        cfg.Notify.WebhookURL,
+		DiscordWebhookURL: cfg.Notify.DiscordWebhookURL,
+		TelegramToken:     cfg.Notify.TelegramToken,
+		TelegramChatID:    cfg.Notify.TelegramChatID,
+		Templates:         cfg.Notify.Templates,
+		Targets:           make(map[string]miner.NotifyTarget, len(cfg.Notify.Targets)),
+	}
+	for name, target := range cfg.Notify.Targets {
+		minr.NotifySettings.Targets[name] = miner.NotifyTarget(target)
+	}
+	minr.TwitchSettings.DropsRewardWhitelist = cfg.DropsRewardWhitelist
+	minr.TwitchSettings.MaxConcurrentGQL = cfg.GQLMaxConcurrent
+	minr.TwitchSettings.MaxConcurrentWatch = cfg.WatchMaxConcurrent
+	minr.TwitchSettings.SpadeExtraProps = cfg.SpadeExtraProps
+	minr.TwitchSettings.WatchProfile = watchProfile(cfg.WatchProfile)
+	minr.TwitchSettings.SpadeMaxAge = time.Duration(cfg.SpadeMaxAgeMinutes) * time.Minute
+	minr.TwitchSettings.MaxAuthFailures = cfg.MaxAuthFailures
+	minr.TwitchSettings.ClaimBonusOnLoad = cfg.ClaimBonusOnLoad
+	minr.TwitchSettings.DebugHTTP = cfg.DebugHTTP
+	minr.TwitchSettings.CommunityGoalRetries = cfg.CommunityGoalRetries
+	minr.ExitOnAuthFailure = cfg.ExitOnAuthFailure
+	minr.RestartOnPanic = cfg.RestartOnPanic
+	minr.PubSubSettings.RecoverPanics = cfg.RestartOnPanic
+	minr.TwitchSettings.RecoverPanics = cfg.RestartOnPanic
+	minr.PersistClaimedDrops = cfg.PersistClaimedDrops
+	minr.FollowersFallback = cfg.FollowersFallback
+	minr.OnNoStreamers = cfg.OnNoStreamers
+	minr.StartupLoadRetries = cfg.StartupLoadRetries
+	minr.LogWatchEvents = cfg.LogWatchEvents
+	minr.SessionBaseline = cfg.SessionBaseline
+	minr.StreakMinPoints = cfg.StreakMinPoints
+	minr.StreakCatchupAfter = time.Duration(cfg.StreakCatchupAfterMinutes * float64(time.Minute))
+	minr.PubSubSettings.RaidJoinCooldown = time.Duration(cfg.RaidJoinCooldownMinutes * float64(time.Minute))
+	minr.PubSubSettings.MaxPendingPredictions = cfg.MaxPendingPredictions
+	minr.PubSubSettings.ReconnectPresenceGrace = time.Duration(cfg.PresenceGraceSeconds) * time.Second
+	if err := validatePubSubTiming(cfg); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	minr.PubSubSettings.PingIntervalMin = time.Duration(cfg.PubSubPingMinSeconds) * time.Second
+	minr.PubSubSettings.PingIntervalMax = time.Duration(cfg.PubSubPingMaxSeconds) * time.Second
+	minr.PubSubSettings.PongTimeout = time.Duration(cfg.PubSubPongTimeoutSeconds) * time.Second
+	minr.PubSubSettings.BetLogPath = cfg.BetLog
+	minr.PubSubSettings.ConfirmBetViaGQL = cfg.ConfirmBetViaGQL
+	minr.PubSubSettings.EarnedDedupWindow = time.Duration(cfg.PointsDedupSeconds) * time.Second
+	if cfg.SafeMode {
+		minr.PubSubSettings.ReconnectDelay = safeModeReconnectDelay
+	}
 
-	if len(cfg.Streamers) > 0 {
+	if *claimAll {
+		minr.ClaimAll(cfg.Streamers, cfg.MineFollowersToo || len(cfg.Streamers) == 0, entities.FollowersOrderDESC)
+		return
+	}
+	if cfg.MineFollowersToo {
+		minr.MineWithFollowers(cfg.Streamers, entities.FollowersOrderDESC)
+	} else if len(cfg.Streamers) > 0 {
 		minr.Mine(cfg.Streamers)
 	} else {
 		minr.MineFollowers(entities.FollowersOrderDESC)